@@ -4,12 +4,19 @@ import (
 	"encoding/base64"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 const AuthUserKey = "user"
 
 const AuthProxyUserKey = "proxy_user"
 
+// RolesKey is the key of the []string roles of the authenticated user, for RequireRole
+const RolesKey = "roles"
+
+// ClaimsKey is the key of the claims of a verified token, as set by genjwt.JWT
+const ClaimsKey = "claims"
+
 type Accounts map[string]string
 
 type authPair struct {
@@ -84,3 +91,66 @@ func searchCredential(a []authPair, authValue string) (user string, found bool)
 	}
 	return
 }
+
+// BearerToken returns the token of an "Authorization: Bearer <token>" header, the scheme
+// matched case-insensitively, and false if the header is missing or malformed
+func (c *Context) BearerToken() (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(c.Request.Header.Get("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	if token == "" || strings.ContainsAny(token, " \t") {
+		return "", false
+	}
+	return token, true
+}
+
+// Claims returns the claims stored under ClaimsKey by a token authentication middleware,
+// or nil if there are none
+func (c *Context) Claims() map[string]any {
+	claims, _ := GetTyped[map[string]any](c, ClaimsKey)
+	return claims
+}
+
+type RequireRoleConfig struct {
+	Roles []string
+	// Extractor returns the roles of the request's user, by default those under RolesKey,
+	// or else the "roles" claim, see Context.Claims
+	Extractor func(c *Context) []string
+}
+
+// RequireRole aborts with 403 unless the user has one of roles, as set by the auth middleware
+func RequireRole(roles ...string) HandlerFunc {
+	return RequireRoleWithConfig(RequireRoleConfig{Roles: roles})
+}
+
+func RequireRoleWithConfig(config RequireRoleConfig) HandlerFunc {
+	if config.Extractor == nil {
+		config.Extractor = defaultRoles
+	}
+	return func(c *Context) {
+		for _, role := range config.Extractor(c) {
+			for _, r := range config.Roles {
+				if role == r {
+					return
+				}
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, H{"error": "forbidden"})
+	}
+}
+
+func defaultRoles(c *Context) []string {
+	if roles, ok := GetTyped[[]string](c, RolesKey); ok {
+		return roles
+	}
+	claimed, _ := c.Claims()["roles"].([]any)
+	roles := make([]string, 0, len(claimed))
+	for _, role := range claimed {
+		if role, ok := role.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
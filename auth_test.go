@@ -0,0 +1,60 @@
+package gen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerToken(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc.def", "abc.def", true},
+		{"bearer  abc ", "abc", true},
+		{"Basic dXNlcjpwdw==", "", false},
+		{"Bearer", "", false},
+		{"Bearer a b", "", false},
+		{"", "", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		token, ok := newContext(httptest.NewRecorder(), r, nil).BearerToken()
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("%q: got %q, %v, want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(func(c *Context) {
+		switch c.Query("as") {
+		case "admin":
+			c.Set(RolesKey, []string{"viewer", "admin"})
+		case "viewer":
+			c.Set(RolesKey, []string{"viewer"})
+		case "claims":
+			c.Set(ClaimsKey, map[string]any{"roles": []any{"admin"}})
+		}
+	})
+	e.DELETE("/users/:id", RequireRole("admin", "owner"), func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for as, code := range map[string]int{
+		"admin":  http.StatusNoContent,
+		"claims": http.StatusNoContent,
+		"viewer": http.StatusForbidden,
+		"":       http.StatusForbidden,
+	} {
+		if w := performRequest(e, http.MethodDelete, "/users/1?as="+as, nil); w.Code != code {
+			t.Errorf("as %q: got %d, want %d", as, w.Code, code)
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package gen
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Binding decodes a request into obj, see Context.ShouldBindWith
+type Binding interface {
+	Name() string
+	Bind(req *http.Request, obj any) error
+}
+
+// The built-in bindings. Form and Query read the `form` tag, Header the `header` tag,
+// and Uri the `uri` tag of the route params, only available through a Context.
+var (
+	BindingJSON   Binding = jsonBinding{}
+	BindingXML    Binding = xmlBinding{}
+	BindingForm   Binding = formBinding{}
+	BindingQuery  Binding = queryBinding{}
+	BindingHeader Binding = headerBinding{}
+	BindingUri    Binding = uriBinding{}
+)
+
+// EnableDecoderUseNumber makes the JSON binding decode numbers into any or map[string]any
+// as json.Number rather than float64, so integers beyond 2^53 like IDs survive
+var EnableDecoderUseNumber = false
+
+var errNilBody = errors.New("invalid request: empty body")
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (jsonBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errNilBody
+	}
+	decoder := json.NewDecoder(req.Body)
+	if EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	return decoder.Decode(obj)
+}
+
+type xmlBinding struct{}
+
+func (xmlBinding) Name() string {
+	return "xml"
+}
+
+func (xmlBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errNilBody
+	}
+	return xml.NewDecoder(req.Body).Decode(obj)
+}
+
+type formBinding struct{}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+// Bind binds the query string and the x-www-form-urlencoded or multipart body
+func (formBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	return mapForm(obj, "form", newFormValues(req.Form))
+}
+
+// postFormBinding binds the x-www-form-urlencoded or multipart body, without the query string
+type postFormBinding struct{}
+
+func (postFormBinding) Name() string {
+	return "form"
+}
+
+func (postFormBinding) Bind(req *http.Request, obj any) error {
+	if err := req.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	return mapForm(obj, "form", newFormValues(req.PostForm))
+}
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+func (queryBinding) Bind(req *http.Request, obj any) error {
+	return mapForm(obj, "form", newFormValues(req.URL.Query()))
+}
+
+type headerBinding struct{}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+func (headerBinding) Bind(req *http.Request, obj any) error {
+	return mapForm(obj, "header", headerValues(req.Header))
+}
+
+// paramsBinding is implemented by the bindings of route params, which the request lacks
+type paramsBinding interface {
+	BindParams(params httprouter.Params, obj any) error
+}
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+func (uriBinding) Bind(*http.Request, any) error {
+	return errors.New("binding: the uri binding needs the route params, use Context.ShouldBindUri")
+}
+
+func (uriBinding) BindParams(params httprouter.Params, obj any) error {
+	return mapForm(obj, "uri", paramValues(params))
+}
+
+// valueSource is where mapForm reads the values of keys from
+type valueSource interface {
+	get(key string) ([]string, bool)
+}
+
+type headerValues http.Header
+
+func (h headerValues) get(key string) ([]string, bool) {
+	values, ok := h[textproto.CanonicalMIMEHeaderKey(key)]
+	return values, ok
+}
+
+type paramValues httprouter.Params
+
+func (params paramValues) get(key string) ([]string, bool) {
+	for _, p := range params {
+		if p.Key == key {
+			return []string{p.Value}, true
+		}
+	}
+	return nil, false
+}
+
+// formValues are form or query values, with the bracket notation of the keys also
+// available as dotted paths, so `filter[age][gt]` binds a field gt of a struct or map
+// in a field age of a field filter, and `tags[]` or `tags[0]` a slice field tags.
+type formValues map[string][]string
+
+func newFormValues(values map[string][]string) formValues {
+	type indexed struct {
+		index  int
+		values []string
+	}
+	fv := make(formValues, len(values))
+	arrays := make(map[string][]indexed)
+	for key, vals := range values {
+		fv[key] = vals
+		if !strings.HasSuffix(key, "]") {
+			continue
+		}
+		i := strings.IndexByte(key, '[')
+		if i <= 0 {
+			continue
+		}
+		path := []string{key[:i]}
+		for _, seg := range strings.Split(key[i+1:len(key)-1], "][") {
+			path = append(path, seg)
+		}
+		last := path[len(path)-1]
+		if last == "" {
+			name := strings.Join(path[:len(path)-1], ".")
+			fv[name] = append(fv[name], vals...)
+			continue
+		}
+		if n, err := strconv.Atoi(last); err == nil {
+			name := strings.Join(path[:len(path)-1], ".")
+			arrays[name] = append(arrays[name], indexed{n, vals})
+			continue
+		}
+		name := strings.Join(path, ".")
+		fv[name] = append(fv[name], vals...)
+	}
+	for name, items := range arrays {
+		sort.Slice(items, func(i, j int) bool { return items[i].index < items[j].index })
+		for _, item := range items {
+			fv[name] = append(fv[name], item.values...)
+		}
+	}
+	return fv
+}
+
+func (fv formValues) get(key string) ([]string, bool) {
+	values, ok := fv[key]
+	return values, ok
+}
+
+// hasBindingOption reports whether the `binding` tag of sf lists opt, like `binding:"trim"`
+func hasBindingOption(sf reflect.StructField, opt string) bool {
+	for _, o := range strings.Split(sf.Tag.Get("binding"), ",") {
+		if strings.TrimSpace(o) == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// mapForm fills the struct pointed to by obj from src, using the tag, like `form`,
+// or the field name as the key, and `form:"-"` to skip a field.
+// Fields tagged `binding:"trim"` get their values trimmed of surrounding whitespace.
+// time.Time fields tagged like `time_format:"2006-01-02"` or `time_format:"unix"` are parsed by that layout.
+// Nested structs and maps are bound from dotted keys of formValues, see newFormValues.
+func mapForm(obj any, tag string, src valueSource) error {
+	return mapFormTag(obj, formTag{name: tag}, src)
+}
+
+// mapTaggedForm is like mapForm, but only fills the fields carrying the tag, so a source
+// can't reach the fields meant for another, like the body's
+func mapTaggedForm(obj any, tag string, src valueSource) error {
+	return mapFormTag(obj, formTag{name: tag, explicit: true}, src)
+}
+
+// formTag is the tag naming the keys of the fields, and whether fields without it are skipped
+type formTag struct {
+	name     string
+	explicit bool
+}
+
+func mapFormTag(obj any, tag formTag, src valueSource) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("binding: obj must be a non-nil pointer to struct")
+	}
+	return mapStruct(v.Elem(), tag, src, "")
+}
+
+func mapStruct(v reflect.Value, tag formTag, src valueSource, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		field := v.Field(i)
+		key := sf.Tag.Get(tag.name)
+		if key == "-" {
+			continue
+		}
+		if key == "" && sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := mapStruct(field, tag, src, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		if key == "" {
+			if tag.explicit {
+				continue
+			}
+			key = sf.Name
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if fv, ok := src.(formValues); ok && isNested(sf.Type) {
+			if err := mapNested(field, tag, fv, key); err != nil {
+				return err
+			}
+			continue
+		}
+		vals, ok := src.get(key)
+		if !ok {
+			continue
+		}
+		if hasBindingOption(sf, "trim") {
+			trimmed := make([]string, len(vals))
+			for i, val := range vals {
+				trimmed[i] = strings.TrimSpace(val)
+			}
+			vals = trimmed
+		}
+		var err error
+		if layout, ok := sf.Tag.Lookup("time_format"); ok {
+			err = setTimeField(field, vals, layout)
+		} else {
+			err = setField(field, vals)
+		}
+		if err != nil {
+			return fmt.Errorf("binding: field %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// isNested reports whether values of t are bound from the keys under its own, like a struct or map
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if _, ok := formDecoder(t); ok {
+		return false
+	}
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map
+}
+
+var (
+	formDecodersMu sync.RWMutex
+	formDecoders   = map[reflect.Type]func(values []string) (any, error){
+		reflect.TypeOf(time.Time{}): decodeTime,
+		reflect.TypeOf(Time{}): func(values []string) (any, error) {
+			t, err := decodeTime(values)
+			return Time{t.(time.Time)}, err
+		},
+	}
+)
+
+// RegisterFormDecoder registers fn to decode the form, query, header and uri values of
+// fields of type t, like a Money or UUID type. The value fn returns must be assignable to t.
+// time.Time is decoded by TimeLayouts by default, or the layout of a `time_format` tag.
+func RegisterFormDecoder(t reflect.Type, fn func(values []string) (any, error)) {
+	formDecodersMu.Lock()
+	defer formDecodersMu.Unlock()
+	formDecoders[t] = fn
+}
+
+func formDecoder(t reflect.Type) (func(values []string) (any, error), bool) {
+	formDecodersMu.RLock()
+	defer formDecodersMu.RUnlock()
+	fn, ok := formDecoders[t]
+	return fn, ok
+}
+
+// TimeLayouts are the layouts time values are bound from, tried in order, other than
+// in fields with a `time_format` tag. "unix" and "unixmilli" stand for timestamps.
+var TimeLayouts = []string{time.RFC3339, time.DateOnly}
+
+func decodeTime(values []string) (any, error) {
+	if len(values) == 0 || values[0] == "" {
+		return time.Time{}, nil
+	}
+	return parseTime(values[0], TimeLayouts)
+}
+
+// parseTime parses s by the first of layouts matching it
+func parseTime(s string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		switch layout {
+		case "unix", "unixmilli":
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				continue
+			}
+			if layout == "unix" {
+				return time.Unix(n, 0), nil
+			}
+			return time.UnixMilli(n), nil
+		default:
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("time %q matches none of the layouts %q", s, layouts)
+}
+
+// setTimeField sets a time.Time or *time.Time field from vals by layout, for `time_format` tags
+func setTimeField(field reflect.Value, vals []string, layout string) error {
+	if len(vals) == 0 || vals[0] == "" {
+		return nil
+	}
+	t, err := parseTime(vals[0], []string{layout})
+	if err != nil {
+		return err
+	}
+	switch field.Type() {
+	case reflect.TypeOf(time.Time{}):
+		field.Set(reflect.ValueOf(t))
+	case reflect.TypeOf(&time.Time{}):
+		field.Set(reflect.ValueOf(&t))
+	default:
+		return fmt.Errorf("time_format on a field of type %s", field.Type())
+	}
+	return nil
+}
+
+// Time is a time.Time decoding JSON strings by TimeLayouts, and numbers as unix timestamps,
+// for clients not sending RFC 3339. It's bound from form values alike.
+type Time struct {
+	time.Time
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if n, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		t.Time = time.Unix(n, 0)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := parseTime(s, TimeLayouts)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// mapNested fills a struct or map field from the keys under prefix, leaving it untouched without any
+func mapNested(field reflect.Value, tag formTag, fv formValues, prefix string) error {
+	var subkeys []string
+	for key := range fv {
+		if sub, ok := strings.CutPrefix(key, prefix+"."); ok {
+			subkeys = append(subkeys, sub)
+		}
+	}
+	if len(subkeys) == 0 {
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if field.Kind() == reflect.Struct {
+		return mapStruct(field, tag, fv, prefix)
+	}
+	if field.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("binding: field %q: unsupported map key type %s", prefix, field.Type().Key())
+	}
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	elemType := field.Type().Elem()
+	for _, sub := range subkeys {
+		name, rest, nested := strings.Cut(sub, ".")
+		key := reflect.ValueOf(name).Convert(field.Type().Key())
+		if nested && elemType.Kind() != reflect.Interface && !isNested(elemType) {
+			continue // deeper than the map holds, like m[a][b] for map[string]string
+		}
+		elem := reflect.New(elemType).Elem()
+		if old := field.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		switch {
+		case nested && elemType.Kind() == reflect.Interface:
+			m, _ := elem.Interface().(map[string]any)
+			if m == nil {
+				m = make(map[string]any)
+			}
+			setAnyPath(m, strings.Split(rest, "."), fv[prefix+"."+sub])
+			elem.Set(reflect.ValueOf(m))
+		case isNested(elemType):
+			if err := mapNested(elem, tag, fv, prefix+"."+name); err != nil {
+				return err
+			}
+		case elemType.Kind() == reflect.Interface:
+			vals := fv[prefix+"."+sub]
+			if len(vals) == 1 {
+				elem.Set(reflect.ValueOf(vals[0]))
+			} else {
+				elem.Set(reflect.ValueOf(vals))
+			}
+		default:
+			if err := setField(elem, fv[prefix+"."+sub]); err != nil {
+				return fmt.Errorf("binding: field %q: %w", prefix+"."+sub, err)
+			}
+		}
+		field.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// setAnyPath sets vals at path in nested maps of m, for map[string]any fields
+func setAnyPath(m map[string]any, path []string, vals []string) {
+	for _, name := range path[:len(path)-1] {
+		next, _ := m[name].(map[string]any)
+		if next == nil {
+			next = make(map[string]any)
+			m[name] = next
+		}
+		m = next
+	}
+	if len(vals) == 1 {
+		m[path[len(path)-1]] = vals[0]
+	} else {
+		m[path[len(path)-1]] = vals
+	}
+}
+
+func setField(field reflect.Value, vals []string) error {
+	if decode, ok := formDecoder(field.Type()); ok {
+		v, err := decode(vals)
+		if err != nil {
+			return err
+		}
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || !rv.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("decoder of %s returned %T", field.Type(), v)
+		}
+		field.Set(rv)
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, s := range vals {
+			if err := setField(slice.Index(i), []string{s}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	case reflect.Pointer:
+		ptr := reflect.New(field.Type().Elem())
+		if err := setField(ptr.Elem(), vals); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	return setValue(field, vals[0])
+}
+
+func setValue(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package gen
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBindingTrim(t *testing.T) {
+	SetMode(TestMode)
+	type signup struct {
+		Email string   `form:"email" binding:"trim"`
+		Tags  []string `form:"tag" binding:"required,trim"`
+		Note  string   `form:"note"`
+	}
+	e := New()
+	var got signup
+	e.POST("/", func(c *Context) {
+		if err := c.ShouldBind(&got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	performRequest(e, http.MethodPost, "/", strings.NewReader("email=+ann%40x.io+&tag=+a&tag=b+&note=+keep+"), "Content-Type", MIMEPOSTForm)
+	if got.Email != "ann@x.io" || strings.Join(got.Tags, ",") != "a,b" || got.Note != " keep " {
+		t.Errorf("got %+v", got)
+	}
+}
+
+// csvBinding binds a comma-separated body into a *[]string
+type csvBinding struct{}
+
+func (csvBinding) Name() string {
+	return "csv"
+}
+
+func (csvBinding) Bind(req *http.Request, obj any) error {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	*obj.(*[]string) = strings.Split(string(data), ",")
+	return nil
+}
+
+func TestBindWithCustomBinding(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var got []string
+	e.POST("/", func(c *Context) {
+		if err := c.MustBindWith(&got, csvBinding{}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	performRequest(e, http.MethodPost, "/", strings.NewReader("a,b,c"))
+	if strings.Join(got, "|") != "a|b|c" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBindNestedQuery(t *testing.T) {
+	SetMode(TestMode)
+	type rangeFilter struct {
+		Gt int `form:"gt"`
+		Lt int `form:"lt"`
+	}
+	type search struct {
+		Age    rangeFilter       `form:"age"`
+		Labels map[string]string `form:"labels"`
+		Tags   []string          `form:"tags"`
+		IDs    []int             `form:"ids"`
+	}
+	e := New()
+	var got search
+	e.GET("/", func(c *Context) {
+		if err := c.ShouldBindQuery(&got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	performRequest(e, http.MethodGet, "/?age[gt]=18&age[lt]=65&labels[env]=prod&labels[team]=core&tags[]=a&tags[]=b&ids[1]=20&ids[0]=10", nil)
+	want := search{
+		Age:    rangeFilter{Gt: 18, Lt: 65},
+		Labels: map[string]string{"env": "prod", "team": "core"},
+		Tags:   []string{"a", "b"},
+		IDs:    []int{10, 20},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecoderUseNumber(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var got map[string]any
+	e.POST("/", func(c *Context) {
+		got = nil
+		if err := c.ShouldBindJSON(&got); err != nil {
+			t.Error(err)
+		}
+	})
+	body := `{"id":9007199254740993}`
+
+	performRequest(e, http.MethodPost, "/", strings.NewReader(body), "Content-Type", MIMEJSON)
+	if _, ok := got["id"].(float64); !ok {
+		t.Errorf("default: id is %T, want float64", got["id"])
+	}
+
+	EnableDecoderUseNumber = true
+	defer func() { EnableDecoderUseNumber = false }()
+	performRequest(e, http.MethodPost, "/", strings.NewReader(body), "Content-Type", MIMEJSON)
+	if n, ok := got["id"].(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Errorf("UseNumber: id is %T %v, want json.Number 9007199254740993", got["id"], got["id"])
+	}
+}
+
+// cents is a money amount bound from a decimal string like "12.34"
+type cents int64
+
+func TestRegisterFormDecoder(t *testing.T) {
+	SetMode(TestMode)
+	RegisterFormDecoder(reflect.TypeOf(cents(0)), func(values []string) (any, error) {
+		whole, frac, _ := strings.Cut(values[0], ".")
+		n, err := strconv.ParseInt(whole+(frac + "00")[:2], 10, 64)
+		return cents(n), err
+	})
+	e := New()
+	var got struct {
+		Price cents `form:"price"`
+	}
+	var bindErr error
+	e.GET("/", func(c *Context) {
+		bindErr = c.ShouldBindQuery(&got)
+	})
+
+	performRequest(e, http.MethodGet, "/?price=12.3", nil)
+	if bindErr != nil || got.Price != 1230 {
+		t.Errorf("got %d, %v", got.Price, bindErr)
+	}
+	performRequest(e, http.MethodGet, "/?price=abc", nil)
+	if bindErr == nil {
+		t.Error("an invalid amount bound")
+	}
+}
+
+func TestShouldBindUri(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/orders/:id", func(c *Context) {
+		var uri struct {
+			ID int `uri:"id" binding:"required,min=1"`
+		}
+		if err := c.ShouldBindUri(&uri); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "order %d", uri.ID)
+	})
+
+	if w := performRequest(e, http.MethodGet, "/orders/42", nil); w.Code != http.StatusOK || w.Body.String() != "order 42" {
+		t.Errorf("valid: got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, http.MethodGet, "/orders/0", nil); w.Code != http.StatusBadRequest || w.Body.String() != "field ID fails rule required; field ID fails rule min=1" {
+		t.Errorf("zero: got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, http.MethodGet, "/orders/x", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("not a number: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestBindTimeLayouts(t *testing.T) {
+	SetMode(TestMode)
+	type window struct {
+		From  time.Time `form:"from"`
+		Day   time.Time `form:"day"`
+		Until time.Time `form:"until" time_format:"unix"`
+	}
+	e := New()
+	var got window
+	var bindErr error
+	e.GET("/", func(c *Context) {
+		got = window{}
+		bindErr = c.ShouldBindQuery(&got)
+	})
+
+	performRequest(e, http.MethodGet, "/?from=2024-05-06T07:08:09Z&day=2024-05-06&until=1700000000", nil)
+	if bindErr != nil {
+		t.Fatal(bindErr)
+	}
+	if !got.From.Equal(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)) || !got.Day.Equal(time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)) || got.Until.Unix() != 1700000000 {
+		t.Errorf("got %+v", got)
+	}
+	performRequest(e, http.MethodGet, "/?day=06/05/2024", nil)
+	if bindErr == nil {
+		t.Error("an unknown layout bound")
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	SetMode(TestMode)
+	type order struct {
+		Items []struct {
+			Price float64 `json:"price"`
+		} `json:"items"`
+	}
+	// the validator stands in for a JSON Schema library requiring numeric prices
+	validator := SchemaValidatorFunc(func(data []byte) error {
+		var doc struct {
+			Items []map[string]any `json:"items"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		var errs SchemaErrors
+		for i, item := range doc.Items {
+			if _, ok := item["price"].(float64); !ok {
+				errs = append(errs, SchemaError{Path: "/items/" + strconv.Itoa(i) + "/price", Message: "must be a number"})
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	})
+	e := New()
+	var got order
+	e.POST("/", func(c *Context) {
+		if err := c.ShouldBindWith(&got, JSONSchema(validator)); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	w := performRequest(e, http.MethodPost, "/", strings.NewReader(`{"items":[{"price":9.5}]}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusNoContent || len(got.Items) != 1 || got.Items[0].Price != 9.5 {
+		t.Errorf("conforming: %d %+v", w.Code, got)
+	}
+
+	w = performRequest(e, http.MethodPost, "/", strings.NewReader(`{"items":[{"price":1},{"price":"free"}]}`), "Content-Type", MIMEJSON)
+	want := `[{"path":"/items/1/price","message":"must be a number"}]`
+	if w.Code != http.StatusUnprocessableEntity || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("non-conforming: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestShouldBindAll(t *testing.T) {
+	SetMode(TestMode)
+	type update struct {
+		ID      string `uri:"id" form:"id" json:"id"`
+		Version int    `form:"version" json:"version"`
+		Name    string `form:"name" json:"name"`
+		Tenant  string `header:"X-Tenant"`
+	}
+	e := New()
+	var got update
+	e.PUT("/items/:id", func(c *Context) {
+		if err := c.ShouldBindAll(&got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	body := `{"id":"from-body","version":1,"name":"lamp"}`
+	performRequest(e, http.MethodPut, "/items/42?id=from-query&version=7", strings.NewReader(body),
+		"Content-Type", MIMEJSON, "X-Tenant", "acme")
+	want := update{ID: "42", Version: 7, Name: "lamp", Tenant: "acme"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestShouldBindQueryMethods(t *testing.T) {
+	SetMode(TestMode)
+	type search struct {
+		Q    string `form:"q" json:"q"`
+		Page int    `form:"page" json:"page"`
+	}
+	e := New()
+	var got search
+	handler := func(c *Context) {
+		got = search{}
+		if err := c.ShouldBind(&got); err != nil {
+			t.Errorf("%s: %v", c.Method, err)
+		}
+	}
+	e.GET("/search", handler)
+	e.DELETE("/search", handler)
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		// some clients send a Content-Type, and even a body, on every request
+		performRequest(e, method, "/search?q=lamp&page=2", strings.NewReader(`{"q":"body"}`), "Content-Type", MIMEJSON)
+		if got != (search{Q: "lamp", Page: 2}) {
+			t.Errorf("%s: got %+v", method, got)
+		}
+	}
+}
+
+func TestShouldBindJSONMergePatch(t *testing.T) {
+	SetMode(TestMode)
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	}
+	type profile struct {
+		Name    string            `json:"name"`
+		Bio     *string           `json:"bio"`
+		Age     int               `json:"age"`
+		Address address           `json:"address"`
+		Labels  map[string]string `json:"labels"`
+	}
+	bio := "hi"
+	current := profile{
+		Name:    "Ann",
+		Bio:     &bio,
+		Age:     30,
+		Address: address{City: "Oslo", Zip: "0150"},
+		Labels:  map[string]string{"team": "core", "tier": "gold"},
+	}
+	e := New()
+	e.PATCH("/profile", func(c *Context) {
+		if err := c.ShouldBindJSONMergePatch(&current); err != nil {
+			t.Error(err)
+		}
+	})
+
+	patch := `{"bio":null,"address":{"city":"Bergen"},"labels":{"tier":null,"on_call":"yes"}}`
+	performRequest(e, http.MethodPatch, "/profile", strings.NewReader(patch), "Content-Type", MIMEMergePatch)
+	want := profile{
+		Name:    "Ann",
+		Age:     30,
+		Address: address{City: "Bergen", Zip: "0150"},
+		Labels:  map[string]string{"team": "core", "on_call": "yes"},
+	}
+	if !reflect.DeepEqual(current, want) {
+		t.Errorf("got %+v, want %+v", current, want)
+	}
+}
+
+func TestShouldBindAllUntaggedBodyFields(t *testing.T) {
+	SetMode(TestMode)
+	type order struct {
+		ID     int `uri:"id"`
+		Amount int `json:"amount"`
+		Date   string
+	}
+	e := New()
+	var got order
+	e.POST("/orders/:id", func(c *Context) {
+		got = order{}
+		if err := c.ShouldBindAll(&got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	performRequest(e, http.MethodPost, "/orders/7?Amount=1&amount=1&Date=from-query", strings.NewReader(`{"amount":500,"Date":"x"}`),
+		"Content-Type", MIMEJSON, "Date", "from-header")
+	if want := (order{ID: 7, Amount: 500, Date: "x"}); got != want {
+		t.Errorf("json: got %+v, want %+v", got, want)
+	}
+	performRequest(e, http.MethodPost, "/orders/7?Date=from-query", strings.NewReader("Date=x"),
+		"Content-Type", MIMEPOSTForm, "Date", "from-header")
+	if want := (order{ID: 7, Date: "x"}); got != want {
+		t.Errorf("form: got %+v, want %+v", got, want)
+	}
+}
+
+func TestJSONSchemaBodyLimit(t *testing.T) {
+	SetMode(TestMode)
+	validated := false
+	validator := SchemaValidatorFunc(func(data []byte) error {
+		validated = true
+		return nil
+	})
+	e := New()
+	e.POST("/", func(c *Context) {
+		var v map[string]any
+		if c.MustBindWith(&v, JSONSchema(validator)) == nil {
+			c.Status(http.StatusNoContent)
+		}
+	})
+
+	// streamed, so without a Content-Length to reject upfront
+	body := io.MultiReader(strings.NewReader(`{"data":"`), strings.NewReader(strings.Repeat("x", maxSchemaBodySize)), strings.NewReader(`"}`))
+	w := performRequest(e, http.MethodPost, "/", body, "Content-Type", MIMEJSON)
+	if w.Code != http.StatusRequestEntityTooLarge || validated {
+		t.Errorf("got %d, validated %v", w.Code, validated)
+	}
+}
@@ -0,0 +1,106 @@
+package gen
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"regexp"
+	"strings"
+)
+
+type BodyLoggerConfig struct {
+	// MaxSize is the number of bytes logged per body, 4096 by default
+	MaxSize int
+	// ContentTypes are the media types whose bodies get logged, JSON, form and plain text by default
+	ContentTypes []string
+	// RedactFields are the JSON or form fields whose values get masked, "password" and "token" by default
+	RedactFields []string
+	// Redact overrides the masking by RedactFields if set
+	Redact func(body []byte) []byte
+}
+
+// BodyLogger logs the request and response bodies of the allowed content types,
+// truncated to MaxSize and with sensitive fields redacted. Binary bodies are skipped.
+// At most MaxSize+1 bytes of a request body are read ahead, so large uploads still stream.
+func BodyLogger(config BodyLoggerConfig) HandlerFunc {
+	if config.MaxSize <= 0 {
+		config.MaxSize = 4096
+	}
+	if config.ContentTypes == nil {
+		config.ContentTypes = []string{MIMEJSON, MIMEPOSTForm, MIMEPlain}
+	}
+	if config.RedactFields == nil {
+		config.RedactFields = []string{"password", "token"}
+	}
+	if config.Redact == nil {
+		config.Redact = redactFields(config.RedactFields)
+	}
+	loggable := func(contentType string) bool {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		for _, t := range config.ContentTypes {
+			if strings.EqualFold(mediaType, t) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(c *Context) {
+		if c.Request.Body != nil && loggable(c.Request.Header.Get("Content-Type")) {
+			// only read what gets logged, and put it back in front of the rest for the handlers
+			rest := c.Request.Body
+			body, err := io.ReadAll(io.LimitReader(rest, int64(config.MaxSize)+1))
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), rest), rest}
+			if err == nil {
+				log.Printf("| %-7s %s | request body: %s\n", c.Method, c.Path, truncate(config.Redact(body), config.MaxSize))
+			}
+		}
+		w := &bodyLogWriter{ResponseWriter: c.Writer, max: config.MaxSize}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if w.body.Len() > 0 && loggable(w.Header().Get("Content-Type")) {
+			log.Printf("| %-7s %s | response body: %s\n", c.Method, c.Path, truncate(config.Redact(bytes.TrimSpace(w.body.Bytes())), config.MaxSize))
+		}
+	}
+}
+
+// bodyLogWriter tees up to max bytes of the response body
+type bodyLogWriter struct {
+	ResponseWriter
+	body bytes.Buffer
+	max  int
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	if room := w.max - w.body.Len(); room > 0 {
+		w.body.Write(data[:min(room, len(data))])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func truncate(body []byte, max int) []byte {
+	if len(body) > max {
+		return append(body[:max:max], "..."...)
+	}
+	return body
+}
+
+// redactFields masks the values of fields in JSON (`"password": "..."`) and form (`password=...`) bodies,
+// working on truncated bodies too
+func redactFields(fields []string) func(body []byte) []byte {
+	if len(fields) == 0 {
+		return func(body []byte) []byte { return body }
+	}
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	names := "(?i:" + strings.Join(quoted, "|") + ")"
+	jsonRe := regexp.MustCompile(`("` + names + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	formRe := regexp.MustCompile(`((?:^|&)` + names + `=)[^&]*`)
+	return func(body []byte) []byte {
+		body = jsonRe.ReplaceAll(body, []byte(`$1"[REDACTED]"`))
+		return formRe.ReplaceAll(body, []byte(`$1[REDACTED]`))
+	}
+}
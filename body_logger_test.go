@@ -0,0 +1,70 @@
+package gen
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBodyLoggerBoundedRead(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	const size = 1 << 20
+	src := &countingReader{ReadCloser: io.NopCloser(strings.NewReader(`{"password":"secret","data":"` + strings.Repeat("x", size) + `"}`))}
+	var readAhead int64
+	var got int
+	e := New()
+	e.Use(BodyLogger(BodyLoggerConfig{MaxSize: 64}))
+	e.POST("/", func(c *Context) {
+		readAhead = src.n
+		data, _ := io.ReadAll(c.Request.Body)
+		got = len(data)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/", src)
+	r.Header.Set("Content-Type", MIMEJSON)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+
+	if readAhead > 4096 {
+		t.Errorf("BodyLogger read %d bytes ahead of the handler", readAhead)
+	}
+	if want := size + len(`{"password":"secret","data":""}`); got != want {
+		t.Errorf("handler read %d bytes, want %d", got, want)
+	}
+	if out := logs.String(); strings.Contains(out, "secret") || !strings.Contains(out, "[REDACTED]") || !strings.Contains(out, "...") {
+		t.Errorf("log = %q, want a redacted and truncated body", out)
+	}
+}
+
+func TestBodyLoggerSkipsBinary(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	png := "\x89PNG\r\n\x1a\nIHDR"
+	var got string
+	e := New()
+	e.Use(BodyLogger(BodyLoggerConfig{}))
+	e.POST("/avatar", func(c *Context) {
+		data, _ := io.ReadAll(c.Request.Body)
+		got = string(data)
+		c.Data(http.StatusOK, "image/png", data)
+	})
+
+	w := performRequest(e, http.MethodPost, "/avatar", strings.NewReader(png), "Content-Type", "application/octet-stream")
+	if got != png || w.Body.String() != png {
+		t.Errorf("handler read %q, responded %q", got, w.Body.String())
+	}
+	if logs.Len() != 0 {
+		t.Errorf("binary bodies logged: %q", logs.String())
+	}
+}
@@ -0,0 +1,111 @@
+package gen
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// Buffer holds the response in memory and only sends it if the handlers attached no error
+// by Context.Error. Otherwise it's discarded, so the error handler set by Engine.ErrorHandler,
+// or a plain 500 without one, replaces whatever was written before the failure.
+// Responses outgrowing maxSize bytes, or flushed, are sent as they go and can't be discarded.
+func Buffer(maxSize int) HandlerFunc {
+	return func(c *Context) {
+		w := &bufferWriter{
+			ResponseWriter: c.Writer,
+			header:         c.Writer.Header().Clone(),
+			status:         http.StatusOK,
+			max:            maxSize,
+		}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if w.committed {
+			return
+		}
+		if len(c.Errors) > 0 {
+			if c.engine.errorHandler == nil {
+				c.errorPage(http.StatusInternalServerError, "Internal Server Error")
+			}
+			return
+		}
+		if w.written {
+			w.commit()
+			return
+		}
+		header := c.Writer.Header()
+		for k, v := range w.header {
+			header[k] = v
+		}
+	}
+}
+
+type bufferWriter struct {
+	ResponseWriter
+	header    http.Header
+	buf       bytes.Buffer
+	status    int
+	written   bool
+	committed bool
+	max       int
+}
+
+func (w *bufferWriter) Header() http.Header {
+	if w.committed {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.written {
+		return
+	}
+	w.written = true
+	w.status = code
+}
+
+func (w *bufferWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.committed {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.buf.Len()+len(data) > w.max {
+		if err := w.commit(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+func (w *bufferWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+	if !w.committed {
+		w.commit()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *bufferWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferWriter) Written() bool {
+	return w.written
+}
+
+// commit sends the buffered headers and body, then writes go straight through
+func (w *bufferWriter) commit() error {
+	w.committed = true
+	header := w.ResponseWriter.Header()
+	for k := range header {
+		delete(header, k)
+	}
+	for k, v := range w.header {
+		header[k] = v
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.buf.WriteTo(w.ResponseWriter)
+	return err
+}
@@ -0,0 +1,30 @@
+package gen
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestBufferDiscardsOnError(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(Buffer(1 << 10))
+	e.GET("/fail", func(c *Context) {
+		c.SetHeader("X-Partial", "1")
+		c.String(http.StatusOK, "partial body")
+		c.Error(errors.New("lost the database"))
+	})
+	e.GET("/ok", func(c *Context) {
+		c.String(http.StatusCreated, "done")
+	})
+
+	w := performRequest(e, http.MethodGet, "/fail", nil)
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Internal Server Error" || w.Header().Get("X-Partial") != "" {
+		t.Errorf("failed: got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	w = performRequest(e, http.MethodGet, "/ok", nil)
+	if w.Code != http.StatusCreated || w.Body.String() != "done" {
+		t.Errorf("ok: got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,15 @@
+package gen
+
+// Chain bundles middlewares into one, so a common stack like auth+logging+cors can be
+// named and reused on groups and routes. They run in order, with Next and Abort behaving
+// just as if they were registered one by one.
+func Chain(middlewares ...HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		// splice the middlewares in right after this one
+		rest := c.handlers[c.index+1:]
+		handlers := make([]HandlerFunc, 0, len(c.handlers)+len(middlewares))
+		handlers = append(handlers, c.handlers[:c.index+1]...)
+		handlers = append(handlers, middlewares...)
+		c.handlers = append(handlers, rest...)
+	}
+}
@@ -0,0 +1,40 @@
+package gen
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	SetMode(TestMode)
+	var order []string
+	mark := func(name string) HandlerFunc {
+		return func(c *Context) {
+			order = append(order, name)
+			c.Next()
+			order = append(order, "/"+name)
+		}
+	}
+	guard := func(c *Context) {
+		if c.Query("deny") != "" {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+	e := New()
+	e.Use(mark("outer"))
+	e.GET("/", Chain(mark("a"), mark("b"), guard), func(c *Context) {
+		order = append(order, "handler")
+	})
+
+	performRequest(e, http.MethodGet, "/", nil)
+	if got := strings.Join(order, ","); got != "outer,a,b,handler,/b,/a,/outer" {
+		t.Errorf("order = %s", got)
+	}
+
+	order = nil
+	w := performRequest(e, http.MethodGet, "/?deny=1", nil)
+	if got := strings.Join(order, ","); w.Code != http.StatusForbidden || got != "outer,a,b,/b,/a,/outer" {
+		t.Errorf("aborted: got %d, order %s", w.Code, got)
+	}
+}
@@ -0,0 +1,59 @@
+package gen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// paramConstraints are the named constraints of route params, anything else between
+// the angle brackets is taken as a regular expression
+var paramConstraints = map[string]*regexp.Regexp{
+	"int":   regexp.MustCompile(`^-?[0-9]+$`),
+	"uint":  regexp.MustCompile(`^[0-9]+$`),
+	"alpha": regexp.MustCompile(`^[a-zA-Z]+$`),
+	"uuid":  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+}
+
+// parseConstraints strips the constraints like `:id<int>` or `:slug<[a-z-]+>` from path,
+// returning a match func reporting whether the params of a request meet them, since httprouter
+// can't match by pattern. The match func is nil if there are no constraints. Requests failing
+// them are answered by the NoRoute handlers without running any middleware.
+func parseConstraints(path string) (string, func(params httprouter.Params) bool) {
+	if !strings.Contains(path, "<") {
+		return path, nil
+	}
+	constraints := make(map[string]*regexp.Regexp)
+	catchAll := make(map[string]bool)
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') || !strings.HasSuffix(seg, ">") {
+			continue
+		}
+		j := strings.IndexByte(seg, '<')
+		if j < 0 {
+			continue
+		}
+		name, expr := seg[1:j], seg[j+1:len(seg)-1]
+		re, ok := paramConstraints[expr]
+		if !ok {
+			re = regexp.MustCompile("^(?:" + expr + ")$")
+		}
+		constraints[name] = re
+		catchAll[name] = seg[0] == '*'
+		segments[i] = seg[:j]
+	}
+	return strings.Join(segments, "/"), func(params httprouter.Params) bool {
+		for name, re := range constraints {
+			value := params.ByName(name)
+			if catchAll[name] {
+				value = strings.TrimPrefix(value, "/")
+			}
+			if !re.MatchString(value) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,75 @@
+package gen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConstraintGuard(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var paths []string
+	api := e.Group("/users")
+	api.Use(func(c *Context) {
+		paths = append(paths, c.FullPath())
+	})
+	api.GET("/:id<int>", func(c *Context) {
+		c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+	e.GET("/files/*path<.+\\.txt>", func(c *Context) {
+		c.String(http.StatusOK, c.Param("path"))
+	})
+
+	for _, tt := range []struct {
+		path     string
+		code     int
+		body     string
+		fullPath string
+	}{
+		{"/users/42", http.StatusOK, "user 42", "/users/:id"},
+		{"/users/abc", http.StatusNotFound, "404 page not found", ""},
+		{"/files/a/b.txt", http.StatusOK, "/a/b.txt", ""},
+		{"/files/a.png", http.StatusNotFound, "404 page not found", ""},
+	} {
+		paths = nil
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+		if tt.code == http.StatusNotFound && len(paths) > 0 {
+			t.Errorf("%s: group middleware ran on a constraint miss", tt.path)
+		}
+		if len(paths) > 0 && paths[0] != tt.fullPath {
+			t.Errorf("%s: group middleware saw route %q, want %q", tt.path, paths[0], tt.fullPath)
+		}
+	}
+}
+
+func TestConstraintUUID(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var ran int
+	e.Use(func(c *Context) {
+		ran++
+	})
+	orders := e.Group("/orders", func(c *Context) {
+		ran++
+	})
+	orders.GET("/:id<uuid>", func(c *Context) {
+		c.String(http.StatusOK, "order "+c.Param("id"))
+	})
+
+	w := performRequest(e, http.MethodGet, "/orders/3F2504E0-4F89-11D3-9A0C-0305E82C3301", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "order 3F2504E0-4F89-11D3-9A0C-0305E82C3301" || ran != 2 {
+		t.Errorf("valid: got %d %q, middleware ran %d times", w.Code, w.Body.String(), ran)
+	}
+	for _, id := range []string{"3f2504e0-4f89-11d3-9a0c", "3f2504e0-4f89-11d3-9a0c-0305e82c330g", "42"} {
+		ran = 0
+		w := performRequest(e, http.MethodGet, "/orders/"+id, nil)
+		if w.Code != http.StatusNotFound || ran != 0 {
+			t.Errorf("%s: got %d, middleware ran %d times", id, w.Code, ran)
+		}
+	}
+}
@@ -1,21 +1,39 @@
 package gen
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 const (
 	MIMEJSON      = "application/json"
+	MIMEXML       = "application/xml"
+	MIMEXML2      = "text/xml"
 	MIMEHTML      = "text/html"
 	MIMEPlain     = "text/plain"
 	MIMEPOSTForm  = "application/x-www-form-urlencoded"
@@ -25,55 +43,124 @@ const (
 type H map[string]any
 
 type Context struct {
-	Writer  http.ResponseWriter
+	Writer  ResponseWriter
 	Request *http.Request
 
 	Path   string
 	Method string
 	Params httprouter.Params
+	route  *RouteInfo // nil if no route matched
 
 	handlers []HandlerFunc
 	index    int
 	engine   *Engine
+	deferred []func() // run in LIFO order after the chain
+	sameSite http.SameSite
+	bodySize *countingReader // set if the request's Content-Length is unknown
 
 	mu         sync.RWMutex // protects Keys
 	Keys       map[string]any
 	StatusCode int
-	Errors     []*error // Errors is a list of errors attached to all the handlers/middlewares
+	Errors     []*Error // Errors is a list of errors attached to all the handlers/middlewares
 }
 
 func newContext(w http.ResponseWriter, req *http.Request, params httprouter.Params) *Context {
-	return &Context{
-		Writer:  w,
+	c := &Context{
+		Writer:  newResponseWriter(w),
 		Request: req,
 		Path:    req.URL.Path,
 		Method:  req.Method,
 		Params:  params,
 		index:   -1,
 	}
+	if req.ContentLength < 0 && req.Body != nil {
+		c.bodySize = &countingReader{ReadCloser: req.Body}
+		req.Body = c.bodySize
+	}
+	return c
+}
+
+// countingReader counts the bytes read from the body, for RequestSize when the length is unknown
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// RequestSize returns the size of the request body by Content-Length,
+// or the number of bytes read so far if it's unknown, like for chunked bodies
+func (c *Context) RequestSize() int64 {
+	if c.bodySize != nil {
+		return c.bodySize.n
+	}
+	return c.Request.ContentLength
+}
+
+// ResponseSize returns the number of body bytes written so far
+func (c *Context) ResponseSize() int {
+	return c.Writer.Size()
 }
 
 // Copy returns a copy of the current context that can be safely used outside the request's scope.
-// This has to be used when the context has to be passed to a goroutine.
+// This has to be used when the context has to be passed to a goroutine, for reading only:
+// Keys, Params and Errors are copied so neither side sees the other's changes,
+// and writing the response through the copy panics, as it belongs to the original.
 func (c *Context) Copy() *Context {
 	cp := Context{
-		Request: c.Request,
-		Path:    c.Path,
-		Method:  c.Method,
-		index:   len(c.handlers),
-		engine:  c.engine,
+		Writer:     copyWriter{status: c.Writer.Status(), size: c.Writer.Size()},
+		Request:    c.Request,
+		Path:       c.Path,
+		Method:     c.Method,
+		route:      c.route,
+		index:      len(c.handlers),
+		engine:     c.engine,
+		StatusCode: c.StatusCode,
 	}
-	cp.Keys = make(map[string]any, len(c.Keys))
 	c.mu.RLock()
+	cp.Keys = make(map[string]any, len(c.Keys))
 	for k, v := range c.Keys {
 		cp.Keys[k] = v
 	}
 	c.mu.RUnlock()
 	cp.Params = make(httprouter.Params, len(c.Params))
 	copy(cp.Params, c.Params)
+	cp.Errors = append([]*Error(nil), c.Errors...)
 	return &cp
 }
 
+// FullPath returns the pattern of the matched route like "/users/:id", or "" if none matched
+func (c *Context) FullPath() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.Path
+}
+
+// Route returns the matched route with its metadata, like its name and tags, already
+// available to the middlewares, or nil if none matched
+func (c *Context) Route() *RouteInfo {
+	return c.route
+}
+
+// RouteTag reports whether the matched route has tag, e.g. for an auth middleware
+// to let the routes tagged "public" through
+func (c *Context) RouteTag(tag string) bool {
+	if c.route == nil {
+		return false
+	}
+	for _, t := range c.route.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // HandlerName returns the main handler's name
 func (c *Context) HandlerName() string {
 	len_ := len(c.handlers)
@@ -90,21 +177,98 @@ func (c *Context) Next() {
 	}
 }
 
+// Defer registers fn to run after the handler chain completes, even if it aborts or panics,
+// like closing a DB transaction. Deferred functions run in LIFO order.
+func (c *Context) Defer(fn func()) {
+	c.deferred = append(c.deferred, fn)
+}
+
+func (c *Context) runDeferred() {
+	for i := len(c.deferred) - 1; i >= 0; i-- {
+		c.deferred[i]()
+	}
+}
+
 // Abort Note that this will not stop the current handler, often followed by `return`
 func (c *Context) Abort() {
 	c.index = len(c.handlers)
 }
 
+// IsAborted reports whether the chain was aborted, or has no handlers left to run
+func (c *Context) IsAborted() bool {
+	return c.index >= len(c.handlers)
+}
+
+// ClientGone reports whether the request's context is done, as when the client disconnected,
+// for handlers doing expensive work to bail early
+func (c *Context) ClientGone() bool {
+	return c.Request.Context().Err() != nil
+}
+
 func (c *Context) AbortWithStatus(code int) {
 	c.Status(code)
 	c.Abort()
 }
 
+func (c *Context) AbortWithStatusJSON(code int, obj any) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
+// AbortWithStatusString aborts with a plain text body formatted like String
+func (c *Context) AbortWithStatusString(code int, format string, a ...any) {
+	c.Abort()
+	c.String(code, format, a...)
+}
+
+// AbortWithHTML aborts with a rendered template, like an error or maintenance page in a middleware.
+// If a response was already written it only aborts, and without loaded templates
+// it's a render error of ErrNoTemplates like HTML.
+func (c *Context) AbortWithHTML(code int, name string, data any) {
+	c.Abort()
+	if c.Writer.Written() {
+		return
+	}
+	c.HTML(code, name, data)
+}
+
+// FailJSON aborts with an error envelope shared by the whole app, by default
+// {"error": {"code": code, "message": message, "details": details}}, see Engine.ErrorEnvelope
+func (c *Context) FailJSON(code int, message string, details ...any) {
+	envelope := defaultErrorEnvelope
+	if c.engine != nil && c.engine.ErrorEnvelope != nil {
+		envelope = c.engine.ErrorEnvelope
+	}
+	c.AbortWithStatusJSON(code, envelope(code, message, details))
+}
+
+func defaultErrorEnvelope(code int, message string, details []any) any {
+	e := H{"code": code, "message": message}
+	switch len(details) {
+	case 0:
+	case 1:
+		e["details"] = details[0]
+	default:
+		e["details"] = details
+	}
+	return H{"error": e}
+}
+
 func (c *Context) RemoteIP() string {
 	ip, _, _ := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
 	return ip
 }
 
+// Error attaches err to the context, see Errors
+func (c *Context) Error(err error) *Error {
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Err: err}
+	}
+	c.Errors = append(c.Errors, e)
+	return e
+}
+
 func (c *Context) Set(key string, value any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -128,13 +292,54 @@ func (c *Context) MustGet(key string) any {
 	panic("Key \"" + key + "\" does not exist!")
 }
 
+// SetTyped is Set with the value type checked at compile time
+func SetTyped[T any](c *Context, key string, v T) {
+	c.Set(key, v)
+}
+
+// GetTyped returns the value of key if it exists and holds a T
+func GetTyped[T any](c *Context, key string) (T, bool) {
+	value, ok := c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	v, ok := value.(T)
+	return v, ok
+}
+
+// ContextKey is a typed key of a value in Keys, namespaced so it can't collide with string keys
+// or other ContextKeys, even of the same name. Create them with NewContextKey, once.
+type ContextKey[T any] struct {
+	name string
+	key  string
+}
+
+var contextKeys atomic.Uint64
+
+func NewContextKey[T any](name string) ContextKey[T] {
+	return ContextKey[T]{name: name, key: fmt.Sprintf("gen.ContextKey#%d:%s", contextKeys.Add(1), name)}
+}
+
+func (k ContextKey[T]) Name() string {
+	return k.name
+}
+
+func (k ContextKey[T]) Set(c *Context, v T) {
+	c.Set(k.key, v)
+}
+
+// Get returns the value of k if it was set
+func (k ContextKey[T]) Get(c *Context) (T, bool) {
+	return GetTyped[T](c, k.key)
+}
+
 /**********************/
 /******** INPUT *******/
 /**********************/
 
 func (c *Context) Param(key string) string {
-	value := c.Param(key)
-	return value
+	return c.Params.ByName(key)
 }
 
 // PostForm for x-www-form-urlencoded POST
@@ -146,6 +351,215 @@ func (c *Context) Query(key string) string {
 	return c.Request.URL.Query().Get(key)
 }
 
+// QueryString returns the raw query string, without the "?"
+func (c *Context) QueryString() string {
+	return c.Request.URL.RawQuery
+}
+
+// QueryParams returns a copy of the parsed query string
+func (c *Context) QueryParams() url.Values {
+	return c.Request.URL.Query()
+}
+
+// QueryWith returns the query string with the values of the keys in changes replaced,
+// keeping the others, and the keys with no values removed, e.g. for a next page link:
+//
+//	next := c.Path + "?" + c.QueryWith(url.Values{"page": {strconv.Itoa(page + 1)}})
+func (c *Context) QueryWith(changes url.Values) string {
+	query := c.QueryParams()
+	for key, values := range changes {
+		if len(values) == 0 {
+			query.Del(key)
+		} else {
+			query[key] = values
+		}
+	}
+	return query.Encode()
+}
+
+// ErrBodyTooLarge is returned by GetRawData for bodies over Engine.MaxRawDataSize
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// GetRawData reads the whole request body, up to Engine.MaxRawDataSize (10 MiB by default),
+// and restores it so it can be read again, e.g. for binding
+func (c *Context) GetRawData() ([]byte, error) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, nil
+	}
+	limit := int64(10 << 20)
+	if c.engine != nil && c.engine.MaxRawDataSize > 0 {
+		limit = c.engine.MaxRawDataSize
+	}
+	body := c.Request.Body
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(data), body), body}
+		return nil, ErrBodyTooLarge
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// BodyString returns the request body as a string like GetRawData, e.g. to verify a webhook signature
+func (c *Context) BodyString() (string, error) {
+	data, err := c.GetRawData()
+	return string(data), err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ErrMissingKey is returned by the typed query and form getters when the key is absent
+var ErrMissingKey = errors.New("key not found")
+
+func (c *Context) getQuery(key string) (string, bool) {
+	values, ok := c.Request.URL.Query()[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+func (c *Context) getPostForm(key string) (string, bool) {
+	c.Request.FormValue(key) // parses the form
+	values, ok := c.Request.Form[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+func parseInput[T any](source, key, value string, ok bool, parse func(string) (T, error)) (T, error) {
+	var zero T
+	if !ok {
+		return zero, fmt.Errorf("%s %q: %w", source, key, ErrMissingKey)
+	}
+	v, err := parse(value)
+	if err != nil {
+		return zero, fmt.Errorf("%s %q: invalid value %q: %w", source, key, value, err)
+	}
+	return v, nil
+}
+
+func parseBool(s string) (bool, error) {
+	return strconv.ParseBool(s)
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func (c *Context) QueryInt(key string) (int, error) {
+	value, ok := c.getQuery(key)
+	return parseInput("query", key, value, ok, strconv.Atoi)
+}
+
+func (c *Context) QueryBool(key string) (bool, error) {
+	value, ok := c.getQuery(key)
+	return parseInput("query", key, value, ok, parseBool)
+}
+
+func (c *Context) QueryFloat(key string) (float64, error) {
+	value, ok := c.getQuery(key)
+	return parseInput("query", key, value, ok, parseFloat)
+}
+
+func (c *Context) QueryTime(key, layout string) (time.Time, error) {
+	value, ok := c.getQuery(key)
+	return parseInput("query", key, value, ok, func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	})
+}
+
+// DefaultQueryInt returns def if the query value is missing or invalid
+func (c *Context) DefaultQueryInt(key string, def int) int {
+	if v, err := c.QueryInt(key); err == nil {
+		return v
+	}
+	return def
+}
+
+func (c *Context) DefaultQueryBool(key string, def bool) bool {
+	if v, err := c.QueryBool(key); err == nil {
+		return v
+	}
+	return def
+}
+
+func (c *Context) DefaultQueryFloat(key string, def float64) float64 {
+	if v, err := c.QueryFloat(key); err == nil {
+		return v
+	}
+	return def
+}
+
+func (c *Context) PostFormInt(key string) (int, error) {
+	value, ok := c.getPostForm(key)
+	return parseInput("form", key, value, ok, strconv.Atoi)
+}
+
+func (c *Context) PostFormBool(key string) (bool, error) {
+	value, ok := c.getPostForm(key)
+	return parseInput("form", key, value, ok, parseBool)
+}
+
+func (c *Context) PostFormFloat(key string) (float64, error) {
+	value, ok := c.getPostForm(key)
+	return parseInput("form", key, value, ok, parseFloat)
+}
+
+func (c *Context) PostFormTime(key, layout string) (time.Time, error) {
+	value, ok := c.getPostForm(key)
+	return parseInput("form", key, value, ok, func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	})
+}
+
+// DefaultPostFormInt returns def if the form value is missing or invalid
+func (c *Context) DefaultPostFormInt(key string, def int) int {
+	if v, err := c.PostFormInt(key); err == nil {
+		return v
+	}
+	return def
+}
+
+func (c *Context) DefaultPostFormBool(key string, def bool) bool {
+	if v, err := c.PostFormBool(key); err == nil {
+		return v
+	}
+	return def
+}
+
+func (c *Context) DefaultPostFormFloat(key string, def float64) float64 {
+	if v, err := c.PostFormFloat(key); err == nil {
+		return v
+	}
+	return def
+}
+
+// MultipartReader returns a streaming reader of the multipart body, to process the parts
+// one at a time instead of buffering them like ParseMultipartForm
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	if ct := c.ContentType(); ct != MIMEMultipart {
+		return nil, fmt.Errorf("%w: content type %q", http.ErrNotMultipart, ct)
+	}
+	return c.Request.MultipartReader()
+}
+
+// ContentType returns the request's Content-Type without parameters like charset
+func (c *Context) ContentType() string {
+	ct := c.Request.Header.Get("Content-Type")
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	return strings.TrimSpace(ct)
+}
+
 func (c *Context) Cookie(name string) (string, error) {
 	cookie, err := c.Request.Cookie(name)
 	if err != nil {
@@ -155,6 +569,185 @@ func (c *Context) Cookie(name string) (string, error) {
 	return val, nil
 }
 
+/***********************/
+/******* BINDING *******/
+/***********************/
+
+// ShouldBind picks the binding by Content-Type: JSON, XML, form or multipart form,
+// and the query string when there is none. GET, HEAD and DELETE requests, which carry
+// no body by convention, always bind the query string whatever their Content-Type.
+// Unlike Bind, it only returns the error.
+func (c *Context) ShouldBind(obj any) error {
+	switch c.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return c.ShouldBindWith(obj, BindingQuery)
+	}
+	switch c.ContentType() {
+	case MIMEJSON:
+		return c.ShouldBindWith(obj, BindingJSON)
+	case MIMEXML, MIMEXML2:
+		return c.ShouldBindWith(obj, BindingXML)
+	case MIMEPOSTForm, MIMEMultipart:
+		return c.ShouldBindWith(obj, BindingForm)
+	case "":
+		return c.ShouldBindWith(obj, BindingQuery)
+	}
+	return fmt.Errorf("binding: unsupported content type %q", c.ContentType())
+}
+
+// ShouldBindWith binds the request into obj by b, only returning the error
+func (c *Context) ShouldBindWith(obj any, b Binding) error {
+	if pb, ok := b.(paramsBinding); ok {
+		return pb.BindParams(c.Params, obj)
+	}
+	return b.Bind(c.Request, obj)
+}
+
+func (c *Context) ShouldBindJSON(obj any) error {
+	return c.ShouldBindWith(obj, BindingJSON)
+}
+
+func (c *Context) ShouldBindXML(obj any) error {
+	return c.ShouldBindWith(obj, BindingXML)
+}
+
+func (c *Context) ShouldBindQuery(obj any) error {
+	return c.ShouldBindWith(obj, BindingQuery)
+}
+
+// ShouldBindForm binds the query string and the x-www-form-urlencoded or multipart body
+func (c *Context) ShouldBindForm(obj any) error {
+	return c.ShouldBindWith(obj, BindingForm)
+}
+
+func (c *Context) ShouldBindHeader(obj any) error {
+	return c.ShouldBindWith(obj, BindingHeader)
+}
+
+// ShouldBindUri binds the route params, then checks the `binding` rules by Validate,
+// so e.g. `uri:"id" binding:"required,min=1"` rejects /orders/0
+func (c *Context) ShouldBindUri(obj any) error {
+	if err := c.ShouldBindWith(obj, BindingUri); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// ShouldBindAll binds obj from all the sources of the request: the route params by `uri` tags,
+// the query by `form` tags, the headers by `header` tags and the body by Content-Type like
+// ShouldBind. On collisions the sources win in that order, so a param can't be overridden
+// by the body. The route params, query and headers only bind the fields carrying their tag, so
+// they can't override untagged fields of the body. Each source only sets the fields it has
+// values for, and the first error is returned.
+func (c *Context) ShouldBindAll(obj any) error {
+	if c.Request.ContentLength != 0 || len(c.Request.TransferEncoding) > 0 {
+		var b Binding
+		switch c.ContentType() {
+		case MIMEJSON:
+			b = BindingJSON
+		case MIMEXML, MIMEXML2:
+			b = BindingXML
+		case MIMEPOSTForm, MIMEMultipart:
+			// only the body's values, the query's are bound below by their tags
+			b = postFormBinding{}
+		default:
+			return fmt.Errorf("binding: unsupported content type %q", c.ContentType())
+		}
+		if err := c.ShouldBindWith(obj, b); err != nil {
+			return err
+		}
+	}
+	if err := mapTaggedForm(obj, "header", headerValues(c.Request.Header)); err != nil {
+		return err
+	}
+	if err := mapTaggedForm(obj, "form", newFormValues(c.Request.URL.Query())); err != nil {
+		return err
+	}
+	return mapTaggedForm(obj, "uri", paramValues(c.Params))
+}
+
+// Bind is like ShouldBind, but on failure it also aborts with 400, or 413 for a body over
+// MaxBodyBytes, and a JSON error body
+func (c *Context) Bind(obj any) error {
+	return c.mustBind(c.ShouldBind(obj))
+}
+
+// MustBindWith is like ShouldBindWith, but on failure it also aborts with 400 and a JSON error body
+func (c *Context) MustBindWith(obj any, b Binding) error {
+	return c.mustBind(c.ShouldBindWith(obj, b))
+}
+
+func (c *Context) BindJSON(obj any) error {
+	return c.MustBindWith(obj, BindingJSON)
+}
+
+func (c *Context) BindXML(obj any) error {
+	return c.MustBindWith(obj, BindingXML)
+}
+
+func (c *Context) BindQuery(obj any) error {
+	return c.MustBindWith(obj, BindingQuery)
+}
+
+func (c *Context) BindForm(obj any) error {
+	return c.MustBindWith(obj, BindingForm)
+}
+
+func (c *Context) BindHeader(obj any) error {
+	return c.MustBindWith(obj, BindingHeader)
+}
+
+func (c *Context) BindUri(obj any) error {
+	return c.mustBind(c.ShouldBindUri(obj))
+}
+
+func (c *Context) BindAll(obj any) error {
+	return c.mustBind(c.ShouldBindAll(obj))
+}
+
+// BindAndValidate binds by Content-Type like ShouldBind, then checks the `binding` rules by Validate.
+// On failure it aborts with 400 if the request can't be parsed, or 422 listing the failing fields.
+// A malformed `binding` tag is attached by Context.Error for the ErrorHandler, or answered with 500.
+func (c *Context) BindAndValidate(obj any) error {
+	if err := c.ShouldBind(obj); err != nil {
+		return c.mustBind(err)
+	}
+	err := Validate(obj)
+	var fields ValidationErrors
+	if errors.As(err, &fields) {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, H{"error": "validation failed", "fields": fields})
+		return err
+	}
+	if err != nil {
+		// a malformed binding tag is a bug of the server, not of the request
+		c.Error(err)
+		c.Abort()
+		if c.engine.errorHandler == nil {
+			c.errorPage(http.StatusInternalServerError, "Internal Server Error")
+		}
+		return err
+	}
+	return nil
+}
+
+// MustBindJSON binds a JSON body into obj, panicking with a *BindError on failure,
+// for Recovery to turn into a 400
+func (c *Context) MustBindJSON(obj any) {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		panic(&BindError{Err: err})
+	}
+}
+
+func (c *Context) mustBind(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, H{"error": err.Error()})
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": err.Error()})
+	}
+	return err
+}
+
 /***********************/
 /******** OUTPUT *******/
 /***********************/
@@ -163,47 +756,324 @@ func (c *Context) Status(code int) {
 	c.Writer.WriteHeader(code)
 }
 
+// NoContent writes a status without a body, like 204, its Content-Type dropped by the writer
+func (c *Context) NoContent(code int) {
+	c.Status(code)
+}
+
+// GetStatus returns the status code written, 200 if nothing was written yet
+func (c *Context) GetStatus() int {
+	return c.Writer.Status()
+}
+
 func (c *Context) SetHeader(key, value string) {
 	c.Writer.Header().Set(key, value)
 }
 
+// setContentType sets Content-Type unless the headers are already written
+func (c *Context) setContentType(value string) {
+	if !c.Writer.Written() {
+		c.SetHeader("Content-Type", value)
+	}
+}
+
 func (c *Context) String(code int, format string, a ...any) {
-	c.SetHeader("Content-Type", "text/plain")
+	c.setContentType("text/plain")
 	c.Status(code)
-	c.Writer.Write([]byte(fmt.Sprintf(format, a...)))
+	_, err := c.Writer.Write([]byte(fmt.Sprintf(format, a...)))
+	c.writeError(err)
 }
 
 func (c *Context) JSON(code int, obj any) {
-	c.SetHeader("Content-Type", "application/json")
+	data, err := json.Marshal(obj)
+	if err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.setContentType("application/json")
 	c.Status(code)
-	encoder := json.NewEncoder(c.Writer)
-	if err := encoder.Encode(obj); err != nil {
-		panic(err)
+	_, err = c.Writer.Write(append(data, '\n'))
+	c.writeError(err)
+}
+
+func (c *Context) XML(code int, obj any) {
+	data, err := xml.Marshal(obj)
+	if err != nil {
+		c.RenderError(err)
+		return
 	}
+	c.Data(code, MIMEXML, data)
 }
 
+// CanonicalJSON writes obj as compact JSON whose bytes are stable for the same input,
+// suitable for ETags and signatures. Map keys are sorted, struct fields follow their
+// declaration order, and there is no trailing newline unlike JSON.
+func (c *Context) CanonicalJSON(code int, obj any) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.Data(code, MIMEJSON, data)
+}
+
+// JSONWithETag writes obj as JSON with a weak ETag of its bytes, or just 304 Not Modified
+// if the request's If-None-Match already has it
+func (c *Context) JSONWithETag(code int, obj any) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		c.RenderError(err)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.SetHeader("ETag", etag)
+	if etagMatch(c.Request.Header.Get("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(code, MIMEJSON, data)
+}
+
+// etagMatch reports whether the If-None-Match header lists etag, comparing weakly
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoTemplates is the render error of HTML before LoadHTMLGlob or LoadHTMLFiles
+var ErrNoTemplates = errors.New("gen: no HTML templates loaded")
+
 func (c *Context) HTML(code int, name string, data any) {
-	c.SetHeader("Content-Type", "text/html")
+	if c.engine == nil || c.engine.htmlTemplates == nil {
+		c.RenderError(ErrNoTemplates)
+		return
+	}
+	var buf bytes.Buffer // so a failing template can still get a clean error response
+	if err := c.engine.htmlTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.setContentType("text/html")
 	c.Status(code)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
-		panic(err)
+	_, err := buf.WriteTo(c.Writer)
+	c.writeError(err)
+}
+
+// errorPage writes an error as the page set by Engine.SetErrorPages to clients accepting HTML,
+// as JSON to clients accepting JSON, and as plain text otherwise
+func (c *Context) errorPage(code int, message string) {
+	accept := c.Request.Header.Get("Accept")
+	if name, ok := c.engine.errorPages[code]; ok && c.engine.htmlTemplates != nil && strings.Contains(accept, MIMEHTML) {
+		c.HTML(code, name, H{"code": code, "message": message})
+		return
+	}
+	if strings.Contains(accept, MIMEJSON) {
+		c.JSON(code, H{"error": message})
+		return
+	}
+	c.String(code, message)
+}
+
+// SetTrailer sets a trailer sent after the body, like a checksum computed while streaming.
+// It can be called before or after writing the body, until the handler returns. Over HTTP/1.1
+// trailers need a chunked response, so not one with Content-Length as written by Data.
+func (c *Context) SetTrailer(key, value string) {
+	c.Writer.Header().Set(http.TrailerPrefix+key, value)
+}
+
+// SetContentLength sets Content-Length unless the headers are already written,
+// so the response isn't chunked and the connection can be reused
+func (c *Context) SetContentLength(n int64) {
+	if !c.Writer.Written() {
+		c.SetHeader("Content-Length", strconv.FormatInt(n, 10))
 	}
 }
 
 func (c *Context) Data(code int, contentType string, data []byte) {
-	c.SetHeader("Content-Type", contentType)
+	c.setContentType(contentType)
+	c.SetContentLength(int64(len(data)))
 	c.Status(code)
-	c.Writer.Write(data)
+	_, err := c.Writer.Write(data)
+	c.writeError(err)
+}
+
+// DataFromReader writes the body from reader, with Content-Length unless contentLength is negative
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	c.setContentType(contentType)
+	if contentLength >= 0 {
+		c.SetContentLength(contentLength)
+	}
+	for k, v := range extraHeaders {
+		c.SetHeader(k, v)
+	}
+	c.Status(code)
+	_, err := io.Copy(c.Writer, reader)
+	c.writeError(err)
+}
+
+// writeError records err quietly if the client went away, and handles it as a render error otherwise
+func (c *Context) writeError(err error) {
+	if err == nil {
+		return
+	}
+	if isConnError(err) {
+		c.Error(err)
+		return
+	}
+	c.RenderError(err)
+}
+
+// RenderError attaches err and passes it to Engine.RenderErrorHandler, for renderers
+// outside this package failing to encode a response
+func (c *Context) RenderError(err error) {
+	c.Error(err)
+	if c.engine != nil && c.engine.RenderErrorHandler != nil {
+		c.engine.RenderErrorHandler(c, err)
+		return
+	}
+	defaultRenderErrorHandler(c, err)
+}
+
+func defaultRenderErrorHandler(c *Context, err error) {
+	log.Printf("Render error on %s %s: %v\n", c.Method, c.Path, err)
+	if !c.Writer.Written() {
+		c.Abort()
+		c.String(http.StatusInternalServerError, "Internal Server Error") // not errorPage, whose template may fail too
+	}
+}
+
+// Hijack takes over the connection, e.g. for a protocol upgrade, after which the handlers
+// must not write the response through the Context anymore
+func (c *Context) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return c.Writer.Hijack()
+}
+
+// Push initiates an HTTP/2 server push of target, returning http.ErrNotSupported
+// when the connection can't push, e.g. over HTTP/1.x
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	return c.Writer.Push(target, opts)
 }
 
 func (c *Context) File(filePath string) {
 	http.ServeFile(c.Writer, c.Request, filePath)
 }
 
+// SendFileWithProgress writes the file at filePath, calling onProgress with the bytes sent so far
+// as they are written. A client going away is recorded via Error, other failures are returned.
+func (c *Context) SendFileWithProgress(filePath string, onProgress func(sent, total int64)) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return err
+	}
+	total := info.Size()
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.setContentType(contentType)
+	c.SetContentLength(total)
+	c.Status(http.StatusOK)
+	_, err = io.Copy(&progressWriter{w: c.Writer, total: total, onProgress: onProgress}, f)
+	if err != nil && isConnError(err) {
+		c.Error(err)
+		return nil
+	}
+	return err
+}
+
+type progressWriter struct {
+	w          io.Writer
+	sent       int64
+	total      int64
+	onProgress func(sent, total int64)
+}
+
+func (w *progressWriter) Write(data []byte) (int, error) {
+	n, err := w.w.Write(data)
+	w.sent += int64(n)
+	if n > 0 && w.onProgress != nil {
+		w.onProgress(w.sent, w.total)
+	}
+	return n, err
+}
+
+// DownloadBytes writes data as an attachment named filename, like an export generated in memory
+func (c *Context) DownloadBytes(filename, contentType string, data []byte) {
+	c.Attachment(filename)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// attachment returns the Content-Disposition of a download, with the RFC 5987 filename*
+// for non-ASCII names, and an ASCII fallback for clients without support
+func attachment(filename string) string {
+	ascii := true
+	for i := 0; i < len(filename); i++ {
+		if filename[i] >= utf8.RuneSelf || filename[i] < ' ' {
+			ascii = false
+			break
+		}
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	if ascii {
+		return `attachment; filename="` + quoted.Replace(filename) + `"`
+	}
+	fallback := strings.Map(func(r rune) rune {
+		if r >= utf8.RuneSelf || r < ' ' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return `attachment; filename="` + quoted.Replace(fallback) + `"; filename*=UTF-8''` + encodeRFC5987(filename)
+}
+
+// encodeRFC5987 percent-encodes s but for the attr-chars of RFC 5987
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || strings.IndexByte("!#$&+-.^_`|~", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[ch>>4])
+		b.WriteByte(hex[ch&15])
+	}
+	return b.String()
+}
+
 func (c *Context) Redirect(location string) {
 	http.Redirect(c.Writer, c.Request, location, http.StatusMovedPermanently)
 }
 
+// RedirectToRoute redirects to the route named name, built by Engine.URL with params
+func (c *Context) RedirectToRoute(code int, name string, params map[string]string) error {
+	location, err := c.engine.URL(name, params)
+	if err != nil {
+		return err
+	}
+	http.Redirect(c.Writer, c.Request, location, code)
+	return nil
+}
+
+// SetCookie sets a cookie, with the Engine.CookieDefaults applied to a zero path and false
+// secure and httpOnly. Names prefixed __Secure- are made secure, and __Host- ones also
+// get the path / and no domain, as browsers reject them otherwise.
 func (c *Context) SetCookie(
 	name string,
 	value string,
@@ -213,7 +1083,7 @@ func (c *Context) SetCookie(
 	secure bool,
 	httpOnly bool,
 ) {
-	http.SetCookie(c.Writer, &http.Cookie{
+	cookie := &http.Cookie{
 		Name:     name,
 		Value:    url.QueryEscape(value),
 		MaxAge:   maxAge,
@@ -221,5 +1091,32 @@ func (c *Context) SetCookie(
 		Domain:   domain,
 		Secure:   secure,
 		HttpOnly: httpOnly,
-	})
+		SameSite: c.sameSite,
+	}
+	if c.engine != nil {
+		defaults := c.engine.CookieDefaults
+		if cookie.Path == "" {
+			cookie.Path = defaults.Path
+		}
+		cookie.Secure = cookie.Secure || defaults.Secure
+		cookie.HttpOnly = cookie.HttpOnly || defaults.HttpOnly
+		if cookie.SameSite == 0 {
+			cookie.SameSite = defaults.SameSite
+		}
+	}
+	switch {
+	case strings.HasPrefix(name, "__Host-"):
+		cookie.Path = "/"
+		cookie.Domain = ""
+		cookie.Secure = true
+	case strings.HasPrefix(name, "__Secure-"):
+		cookie.Secure = true
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
+// SetSameSite sets the SameSite attribute of the cookies set by SetCookie later on,
+// overriding Engine.CookieDefaults
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
 }
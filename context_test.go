@@ -0,0 +1,761 @@
+package gen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLWithoutTemplates(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var errs []error
+	e.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "index.tmpl", nil)
+		for _, err := range c.Errors {
+			errs = append(errs, err.Err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrNoTemplates) {
+		t.Errorf("errors = %v, want ErrNoTemplates", errs)
+	}
+}
+
+func TestRenderErrorDefault(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	e := New()
+	var errs []*Error
+	e.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, H{"updates": make(chan int)})
+		errs = c.Errors
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Internal Server Error" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	var unsupported *json.UnsupportedTypeError
+	if len(errs) != 1 || !errors.As(errs[0].Err, &unsupported) {
+		t.Errorf("errors = %v, want a json.UnsupportedTypeError", errs)
+	}
+	if !strings.Contains(logs.String(), "Render error on GET /") {
+		t.Errorf("log %q", logs.String())
+	}
+}
+
+func TestRenderErrorHandler(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var handled error
+	e.RenderErrorHandler = func(c *Context, err error) {
+		handled = err
+		c.AbortWithStatusJSON(http.StatusInternalServerError, H{"error": "render failed"})
+	}
+	e.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, H{"updates": make(chan int)})
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusInternalServerError || w.Body.String() != `{"error":"render failed"}`+"\n" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	var unsupported *json.UnsupportedTypeError
+	if !errors.As(handled, &unsupported) {
+		t.Errorf("handler got %v, want a json.UnsupportedTypeError", handled)
+	}
+}
+
+func TestAbortWithHTMLWithoutTemplates(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	reached := false
+	e.GET("/", func(c *Context) {
+		c.AbortWithHTML(http.StatusServiceUnavailable, "maintenance.tmpl", nil)
+	}, func(c *Context) {
+		reached = true
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if reached {
+		t.Error("handler after AbortWithHTML ran")
+	}
+}
+
+func TestAbortWithHTML(t *testing.T) {
+	SetMode(TestMode)
+	page := filepath.Join(t.TempDir(), "maintenance.tmpl")
+	if err := os.WriteFile(page, []byte(`<p>Back at {{.until}}</p>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.LoadHTMLFiles(page)
+	reached := false
+	e.GET("/", func(c *Context) {
+		c.AbortWithHTML(http.StatusServiceUnavailable, "maintenance.tmpl", H{"until": "10:00"})
+	}, func(c *Context) {
+		reached = true
+		c.String(http.StatusOK, "index")
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "<p>Back at 10:00</p>" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	if reached {
+		t.Error("handler after AbortWithHTML ran")
+	}
+}
+
+func TestBindAbortsOnce(t *testing.T) {
+	SetMode(TestMode)
+	type form struct {
+		Age int `json:"age"`
+	}
+	e := New()
+	var bindErr, shouldErr error
+	reached := false
+	e.POST("/bind", func(c *Context) {
+		var f form
+		bindErr = c.Bind(&f)
+	}, func(c *Context) {
+		reached = true
+	})
+	e.POST("/should", func(c *Context) {
+		var f form
+		shouldErr = c.ShouldBind(&f)
+		if c.IsAborted() || c.Writer.Written() {
+			t.Error("ShouldBind aborted or wrote")
+		}
+		c.String(http.StatusTeapot, "handled")
+	})
+
+	w := performRequest(e, http.MethodPost, "/bind", strings.NewReader(`{"age":`), "Content-Type", MIMEJSON)
+	if bindErr == nil || w.Code != http.StatusBadRequest || reached {
+		t.Errorf("Bind: err %v, status %d, chain went on %v", bindErr, w.Code, reached)
+	}
+	if n := strings.Count(w.Body.String(), `"error"`); n != 1 {
+		t.Errorf("Bind wrote %d error bodies: %q", n, w.Body.String())
+	}
+
+	w = performRequest(e, http.MethodPost, "/should", strings.NewReader(`{"age":"x"}`), "Content-Type", MIMEJSON)
+	if shouldErr == nil || w.Code != http.StatusTeapot || w.Body.String() != "handled" {
+		t.Errorf("ShouldBind: err %v, got %d %q", shouldErr, w.Code, w.Body.String())
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	SetMode(TestMode)
+	type point struct {
+		Y int `json:"y"`
+		X int `json:"x"`
+	}
+	e := New()
+	e.GET("/map", func(c *Context) {
+		c.CanonicalJSON(http.StatusOK, map[string]any{"b": 2, "a": 1, "c": map[string]int{"z": 1, "y": 2}})
+	})
+	e.GET("/struct", func(c *Context) {
+		c.CanonicalJSON(http.StatusOK, point{Y: 2, X: 1})
+	})
+
+	for path, want := range map[string]string{
+		"/map":    `{"a":1,"b":2,"c":{"y":2,"z":1}}`,
+		"/struct": `{"y":2,"x":1}`,
+	} {
+		for i := 0; i < 5; i++ {
+			if body := performRequest(e, http.MethodGet, path, nil).Body.String(); body != want {
+				t.Fatalf("%s: got %q, want %q", path, body, want)
+			}
+		}
+	}
+}
+
+func TestGetTyped(t *testing.T) {
+	c := &Context{}
+	SetTyped(c, "user", "ann")
+
+	if v, ok := GetTyped[string](c, "user"); !ok || v != "ann" {
+		t.Errorf("GetTyped[string] = %q, %v", v, ok)
+	}
+	if v, ok := GetTyped[int](c, "user"); ok || v != 0 {
+		t.Errorf("GetTyped[int] of a string = %d, %v, want 0, false", v, ok)
+	}
+	if _, ok := GetTyped[string](c, "missing"); ok {
+		t.Error("GetTyped of a missing key reported ok")
+	}
+}
+
+func TestHijack(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/raw", func(c *Context) {
+		conn, rw, err := c.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 3\r\nConnection: close\r\n\r\nraw")
+		rw.Flush()
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "raw" {
+		t.Errorf("got %d %q", resp.StatusCode, body)
+	}
+
+	var recErr error
+	e.GET("/recorded", func(c *Context) {
+		_, _, recErr = c.Hijack()
+	})
+	performRequest(e, http.MethodGet, "/recorded", nil)
+	if !errors.Is(recErr, ErrHijackNotSupported) {
+		t.Errorf("Hijack on a recorder = %v, want ErrHijackNotSupported", recErr)
+	}
+}
+
+func TestPushOverHTTP1(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var pushErr error
+	e.GET("/", func(c *Context) {
+		pushErr = c.Push("/app.css", nil)
+		c.String(http.StatusOK, "page")
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !errors.Is(pushErr, http.ErrNotSupported) {
+		t.Errorf("Push = %v, want http.ErrNotSupported", pushErr)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var order []string
+	e.Use(func(c *Context) {
+		c.Defer(func() { order = append(order, "first") })
+	})
+	e.GET("/", func(c *Context) {
+		c.Defer(func() { order = append(order, "second") })
+		c.AbortWithStatus(http.StatusForbidden)
+	}, func(c *Context) {
+		c.Defer(func() { order = append(order, "never") })
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", w.Code)
+	}
+	if strings.Join(order, ",") != "second,first" {
+		t.Errorf("deferred order = %v, want second,first", order)
+	}
+}
+
+func TestFailJSON(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	reached := false
+	e.GET("/", func(c *Context) {
+		c.FailJSON(http.StatusConflict, "taken", H{"field": "email"})
+	}, func(c *Context) {
+		reached = true
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	want := `{"error":{"code":409,"details":{"field":"email"},"message":"taken"}}` + "\n"
+	if w.Code != http.StatusConflict || w.Body.String() != want || reached {
+		t.Errorf("got %d %q, chain went on %v", w.Code, w.Body.String(), reached)
+	}
+
+	e.ErrorEnvelope = func(code int, message string, details []any) any {
+		return H{"status": code, "msg": message}
+	}
+	w = performRequest(e, http.MethodGet, "/", nil)
+	if want := `{"msg":"taken","status":409}` + "\n"; w.Body.String() != want {
+		t.Errorf("custom envelope = %q, want %q", w.Body.String(), want)
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?n=7&ok=true&f=1.5&d=2024-01-02&bad=x",
+		strings.NewReader("n=8&ok=false&f=2.5&d=2024-03-04&bad=y"))
+	r.Header.Set("Content-Type", MIMEPOSTForm)
+	c := newContext(httptest.NewRecorder(), r, nil)
+
+	if v, err := c.QueryInt("n"); v != 7 || err != nil {
+		t.Errorf("QueryInt = %d, %v", v, err)
+	}
+	if v, err := c.QueryBool("ok"); !v || err != nil {
+		t.Errorf("QueryBool = %v, %v", v, err)
+	}
+	if v, err := c.QueryFloat("f"); v != 1.5 || err != nil {
+		t.Errorf("QueryFloat = %v, %v", v, err)
+	}
+	if v, err := c.QueryTime("d", time.DateOnly); v.Month() != time.January || err != nil {
+		t.Errorf("QueryTime = %v, %v", v, err)
+	}
+	if v, err := c.PostFormInt("n"); v != 8 || err != nil {
+		t.Errorf("PostFormInt = %d, %v", v, err)
+	}
+	if v, err := c.PostFormBool("ok"); v || err != nil {
+		t.Errorf("PostFormBool = %v, %v", v, err)
+	}
+	if v, err := c.PostFormFloat("f"); v != 2.5 || err != nil {
+		t.Errorf("PostFormFloat = %v, %v", v, err)
+	}
+	if v, err := c.PostFormTime("d", time.DateOnly); v.Month() != time.March || err != nil {
+		t.Errorf("PostFormTime = %v, %v", v, err)
+	}
+
+	if _, err := c.QueryInt("bad"); err == nil || errors.Is(err, ErrMissingKey) || err.Error() != `query "bad": invalid value "x": strconv.Atoi: parsing "x": invalid syntax` {
+		t.Errorf("QueryInt of an invalid value = %v", err)
+	}
+	if _, err := c.PostFormBool("bad"); err == nil || errors.Is(err, ErrMissingKey) {
+		t.Errorf("PostFormBool of an invalid value = %v", err)
+	}
+	if _, err := c.QueryFloat("missing"); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("QueryFloat of a missing key = %v, want ErrMissingKey", err)
+	}
+	if _, err := c.PostFormTime("missing", time.DateOnly); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("PostFormTime of a missing key = %v, want ErrMissingKey", err)
+	}
+	if v := c.DefaultQueryInt("bad", 3); v != 3 {
+		t.Errorf("DefaultQueryInt = %d, want 3", v)
+	}
+}
+
+func TestSendFileWithProgress(t *testing.T) {
+	SetMode(TestMode)
+	file := filepath.Join(t.TempDir(), "data.bin")
+	data := bytes.Repeat([]byte("0123456789"), 20_000)
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	var sent []int64
+	e.GET("/", func(c *Context) {
+		err := c.SendFileWithProgress(file, func(n, total int64) {
+			if total != int64(len(data)) {
+				t.Errorf("total = %d, want %d", total, len(data))
+			}
+			sent = append(sent, n)
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if !bytes.Equal(w.Body.Bytes(), data) || w.Header().Get("Content-Length") != "200000" {
+		t.Errorf("got %d bytes, Content-Length %q", w.Body.Len(), w.Header().Get("Content-Length"))
+	}
+	if len(sent) < 2 || sent[len(sent)-1] != int64(len(data)) {
+		t.Fatalf("progress = %v, want several calls up to %d", sent, len(data))
+	}
+	for i := 1; i < len(sent); i++ {
+		if sent[i] <= sent[i-1] {
+			t.Errorf("progress went from %d to %d", sent[i-1], sent[i])
+		}
+	}
+}
+
+func TestJSONWithETag(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/", func(c *Context) {
+		c.JSONWithETag(http.StatusOK, H{"v": 1})
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || w.Body.String() != `{"v":1}` {
+		t.Fatalf("miss: got %d %q with ETag %q", w.Code, w.Body.String(), etag)
+	}
+	w = performRequest(e, http.MethodGet, "/", nil, "If-None-Match", etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("hit: got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodGet, "/", nil, "If-None-Match", `W/"stale"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != etag {
+		t.Errorf("stale: got %d with ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestMultipartReader(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	fw, _ := mw.CreateFormFile("file", "data.csv")
+	fw.Write([]byte("a,b\n1,2\n"))
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	c := newContext(httptest.NewRecorder(), r, nil)
+	mr, err := c.MultipartReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.FormName()+"="+string(data))
+	}
+	if got := strings.Join(parts, "|"); got != "title=report|file=a,b\n1,2\n" {
+		t.Errorf("parts = %q", got)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", MIMEJSON)
+	if _, err := newContext(httptest.NewRecorder(), r, nil).MultipartReader(); !errors.Is(err, http.ErrNotMultipart) {
+		t.Errorf("JSON body: err = %v, want http.ErrNotMultipart", err)
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/data", func(c *Context) {
+		c.Data(http.StatusOK, "text/plain", []byte("hello"))
+	})
+	e.GET("/reader", func(c *Context) {
+		c.DataFromReader(http.StatusOK, 6, "text/plain", strings.NewReader("stream"), nil)
+	})
+	e.GET("/unknown", func(c *Context) {
+		c.DataFromReader(http.StatusOK, -1, "text/plain", strings.NewReader("chunks"), nil)
+	})
+
+	for path, want := range map[string]string{"/data": "5", "/reader": "6", "/unknown": ""} {
+		if got := performRequest(e, http.MethodGet, path, nil).Header().Get("Content-Length"); got != want {
+			t.Errorf("%s: Content-Length = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBodyString(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.MaxRawDataSize = 32
+	e.POST("/", func(c *Context) {
+		raw, err := c.BodyString()
+		if err != nil {
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		var v struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&v); err != nil {
+			t.Error(err)
+		}
+		c.String(http.StatusOK, "%s|%s", raw, v.Name)
+	})
+
+	w := performRequest(e, http.MethodPost, "/", strings.NewReader(`{"name":"ann"}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusOK || w.Body.String() != `{"name":"ann"}|ann` {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodPost, "/", strings.NewReader(`{"name":"`+strings.Repeat("a", 32)+`"}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != ErrBodyTooLarge.Error() {
+		t.Errorf("over the cap: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCopy(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	type result struct {
+		user, id, route string
+		panicked        bool
+	}
+	results := make(chan result, 1)
+	e.GET("/users/:id", func(c *Context) {
+		c.Set("user", "ann")
+		cp := c.Copy()
+		c.Set("user", "changed")
+		go func() {
+			var r result
+			r.user, _ = GetTyped[string](cp, "user")
+			r.id = cp.Param("id")
+			r.route = cp.FullPath()
+			func() {
+				defer func() { r.panicked = recover() != nil }()
+				cp.String(http.StatusOK, "from the goroutine")
+			}()
+			results <- r
+		}()
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := performRequest(e, http.MethodGet, "/users/7", nil)
+	r := <-results
+	if r.user != "ann" || r.id != "7" || r.route != "/users/:id" {
+		t.Errorf("copy read %+v", r)
+	}
+	if !r.panicked {
+		t.Error("writing through the copy didn't panic")
+	}
+	if w.Body.String() != "ok" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+func TestSetCookieDefaults(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.CookieDefaults = CookieDefaults{Path: "/app", HttpOnly: true, SameSite: http.SameSiteStrictMode}
+	e.GET("/", func(c *Context) {
+		c.SetCookie("plain", "v", 60, "", "", false, false)
+		c.SetCookie("__Host-sid", "v", 60, "/x", "example.com", false, false)
+		c.SetCookie("__Secure-pref", "v", 60, "/y", "example.com", false, false)
+	})
+
+	cookies := map[string]*http.Cookie{}
+	for _, c := range performRequest(e, http.MethodGet, "/", nil).Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	if c := cookies["plain"]; c.Path != "/app" || !c.HttpOnly || c.Secure || c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("plain = %+v", c)
+	}
+	if c := cookies["__Host-sid"]; c.Path != "/" || c.Domain != "" || !c.Secure {
+		t.Errorf("__Host- = %+v", c)
+	}
+	if c := cookies["__Secure-pref"]; c.Path != "/y" || c.Domain != "example.com" || !c.Secure {
+		t.Errorf("__Secure- = %+v", c)
+	}
+}
+
+func TestRequestAndResponseSize(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var reqSize int64
+	var respSize int
+	e.Use(func(c *Context) {
+		c.Next()
+		reqSize, respSize = c.RequestSize(), c.ResponseSize()
+	})
+	e.POST("/", func(c *Context) {
+		io.Copy(io.Discard, c.Request.Body)
+		c.String(http.StatusOK, "twelve bytes")
+	})
+
+	performRequest(e, http.MethodPost, "/", strings.NewReader("hello"))
+	if reqSize != 5 || respSize != 12 {
+		t.Errorf("sized body: request %d, response %d", reqSize, respSize)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("chunked body")))
+	r.ContentLength = -1
+	e.ServeHTTP(httptest.NewRecorder(), r)
+	if reqSize != 12 {
+		t.Errorf("chunked body: request %d, want 12", reqSize)
+	}
+}
+
+func TestAbortWithStatusString(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	reached := false
+	e.GET("/", func(c *Context) {
+		c.AbortWithStatusString(http.StatusPaymentRequired, "quota of %d reached", 100)
+	}, func(c *Context) {
+		reached = true
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusPaymentRequired || w.Body.String() != "quota of 100 reached" || reached {
+		t.Errorf("got %d %q, chain went on %v", w.Code, w.Body.String(), reached)
+	}
+}
+
+func TestClientGoneAndIsAborted(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var gone, abortedBefore, abortedAfter bool
+	e.GET("/", func(c *Context) {
+		gone = c.ClientGone()
+		abortedBefore = c.IsAborted()
+		if c.Query("abort") != "" {
+			c.Abort()
+		}
+		abortedAfter = c.IsAborted()
+	}, func(c *Context) {})
+
+	performRequest(e, http.MethodGet, "/", nil)
+	if gone || abortedBefore || abortedAfter {
+		t.Errorf("live request: gone %v, aborted %v then %v", gone, abortedBefore, abortedAfter)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodGet, "/?abort=1", nil).WithContext(ctx)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+	if !gone || abortedBefore || !abortedAfter {
+		t.Errorf("cancelled request: gone %v, aborted %v then %v", gone, abortedBefore, abortedAfter)
+	}
+}
+
+func TestQueryWith(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items?q=red+shoes&page=2&tag=a&tag=b&cursor=x", nil)
+	c := newContext(httptest.NewRecorder(), r, nil)
+
+	next := c.QueryWith(url.Values{"page": {"3"}, "cursor": nil})
+	if next != "page=3&q=red+shoes&tag=a&tag=b" {
+		t.Errorf("next page = %q", next)
+	}
+	if c.QueryString() != "q=red+shoes&page=2&tag=a&tag=b&cursor=x" {
+		t.Errorf("the request's query changed: %q", c.QueryString())
+	}
+}
+
+func TestDownloadBytes(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/export", func(c *Context) {
+		c.DownloadBytes(c.Query("name"), "text/csv", []byte("id,total\n1,9.50\n"))
+	})
+
+	w := performRequest(e, http.MethodGet, "/export?name=orders.csv", nil)
+	if w.Header().Get("Content-Disposition") != `attachment; filename="orders.csv"` {
+		t.Errorf("ascii: %q", w.Header().Get("Content-Disposition"))
+	}
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" || w.Body.String() != "id,total\n1,9.50\n" {
+		t.Errorf("body: %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+
+	w = performRequest(e, http.MethodGet, "/export?name="+url.QueryEscape(`été "Q1".csv`), nil)
+	want := `attachment; filename="_t_ \"Q1\".csv"; filename*=UTF-8''%C3%A9t%C3%A9%20%22Q1%22.csv`
+	if w.Header().Get("Content-Disposition") != want {
+		t.Errorf("non-ascii: %q", w.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestSetTrailer(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/export", func(c *Context) {
+		c.SetHeader("Content-Type", "text/plain")
+		var n int
+		for _, chunk := range []string{"one\n", "two\n", "three\n"} {
+			w, _ := c.Writer.Write([]byte(chunk))
+			n += w
+			c.Writer.Flush()
+		}
+		c.SetTrailer("X-Rows", "3")
+		c.SetTrailer("X-Bytes", strconv.Itoa(n))
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "one\ntwo\nthree\n" {
+		t.Errorf("body %q", body)
+	}
+	if resp.Trailer.Get("X-Rows") != "3" || resp.Trailer.Get("X-Bytes") != "14" {
+		t.Errorf("trailers %v", resp.Trailer)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var before, after int
+	e.Use(func(c *Context) {
+		before = c.GetStatus()
+		c.Next()
+		after = c.GetStatus()
+	})
+	e.GET("/created", func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	e.GET("/implicit", func(c *Context) {
+		c.Writer.Write([]byte("ok"))
+	})
+
+	performRequest(e, http.MethodGet, "/created", nil)
+	if before != http.StatusOK || after != http.StatusCreated {
+		t.Errorf("after Status: %d then %d", before, after)
+	}
+	performRequest(e, http.MethodGet, "/implicit", nil)
+	if after != http.StatusOK {
+		t.Errorf("implicit: got %d", after)
+	}
+}
+
+func TestContextKey(t *testing.T) {
+	// two packages each picking "user" for their key
+	authUser := NewContextKey[string]("user")
+	auditUser := NewContextKey[int]("user")
+	c := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), nil)
+
+	authUser.Set(c, "ann")
+	auditUser.Set(c, 42)
+	c.Set("user", "plain")
+
+	if v, ok := authUser.Get(c); !ok || v != "ann" {
+		t.Errorf("authUser = %q, %v", v, ok)
+	}
+	if v, ok := auditUser.Get(c); !ok || v != 42 {
+		t.Errorf("auditUser = %d, %v", v, ok)
+	}
+	if v, _ := c.Get("user"); v != "plain" {
+		t.Errorf("string key = %v", v)
+	}
+	if authUser.Name() != "user" {
+		t.Errorf("Name() = %q", authUser.Name())
+	}
+	if _, ok := NewContextKey[string]("user").Get(c); ok {
+		t.Error("a new key of the same name found a value")
+	}
+}
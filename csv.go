@@ -0,0 +1,106 @@
+package gen
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const MIMECSV = "text/csv"
+
+// CSV writes records as text/csv. Set the disposition with Attachment first to have it downloaded.
+func (c *Context) CSV(code int, records [][]string) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.Data(code, MIMECSV+"; charset=utf-8", buf.Bytes())
+}
+
+// CSVStructs writes a slice of structs as CSV, a header row first. The columns are the exported
+// fields, named by their `csv` tags or else their names, and `csv:"-"` skips a field.
+// Values implementing encoding.TextMarshaler are written by it, others formatted by fmt.
+func (c *Context) CSVStructs(code int, slice any) {
+	records, err := csvRecords(slice)
+	if err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.CSV(code, records)
+}
+
+// Attachment sets the Content-Disposition for the response to be downloaded as filename
+func (c *Context) Attachment(filename string) {
+	c.SetHeader("Content-Disposition", attachment(filename))
+}
+
+func csvRecords(slice any) ([][]string, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("gen: CSVStructs of %T, not a slice", slice)
+	}
+	t := v.Type().Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("gen: CSVStructs of a slice of non-structs")
+	}
+	var header []string
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("csv")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+	records := make([][]string, 0, v.Len()+1)
+	records = append(records, header)
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Pointer && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		record := make([]string, len(fields))
+		if elem.Kind() == reflect.Struct {
+			for j, f := range fields {
+				s, err := csvValue(elem.Field(f))
+				if err != nil {
+					return nil, err
+				}
+				record[j] = s
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func csvValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+		v = v.Elem()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
@@ -0,0 +1,47 @@
+package gen
+
+import (
+	"encoding/csv"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVStructs(t *testing.T) {
+	SetMode(TestMode)
+	type order struct {
+		ID       int       `csv:"id"`
+		Customer string    `csv:"customer"`
+		Placed   time.Time `csv:"placed"`
+		Note     *string
+		secret   string
+		Internal string `csv:"-"`
+	}
+	note := "leave at door, \"back\"\nporch"
+	placed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := New()
+	e.GET("/orders.csv", func(c *Context) {
+		c.CSVStructs(http.StatusOK, []*order{
+			{ID: 1, Customer: "Ann", Placed: placed, Note: &note, secret: "s", Internal: "i"},
+			{ID: 2, Customer: "Bo, Jr.", Placed: placed},
+		})
+	})
+
+	w := performRequest(e, http.MethodGet, "/orders.csv", nil)
+	if w.Header().Get("Content-Type") != MIMECSV+"; charset=utf-8" {
+		t.Errorf("Content-Type %q", w.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "customer", "placed", "Note"},
+		{"1", "Ann", "2024-03-01T12:00:00Z", note},
+		{"2", "Bo, Jr.", "2024-03-01T12:00:00Z", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q", records)
+	}
+}
@@ -0,0 +1,53 @@
+package gen
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type DecompressConfig struct {
+	// MaxSize caps the decompressed body, against decompression bombs, 10 MiB by default.
+	// Reading past it fails with an *http.MaxBytesError.
+	MaxSize int64
+}
+
+// Decompress decodes request bodies with Content-Encoding gzip or deflate, so binding
+// reads them as usual. Other encodings are rejected with 415.
+func Decompress() HandlerFunc {
+	return DecompressWithConfig(DecompressConfig{})
+}
+
+func DecompressWithConfig(config DecompressConfig) HandlerFunc {
+	if config.MaxSize <= 0 {
+		config.MaxSize = 10 << 20
+	}
+	return func(c *Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.Request.Header.Get("Content-Encoding")))
+		if encoding == "" || encoding == "identity" || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			return
+		}
+		var r io.ReadCloser
+		var err error
+		switch encoding {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(c.Request.Body)
+		case "deflate":
+			r, err = zlib.NewReader(c.Request.Body)
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, H{"error": "unsupported content encoding " + encoding})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": "invalid " + encoding + " body"})
+			return
+		}
+		body := c.Request.Body
+		c.Request.Body = readCloser{http.MaxBytesReader(c.Writer, r, config.MaxSize), body}
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+	}
+}
@@ -0,0 +1,48 @@
+package gen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func gzipped(s string) *bytes.Buffer {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return &buf
+}
+
+func TestDecompress(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(DecompressWithConfig(DecompressConfig{MaxSize: 1 << 10}))
+	var got struct {
+		Name string `json:"name"`
+	}
+	e.POST("/", func(c *Context) {
+		if c.Bind(&got) == nil {
+			c.String(http.StatusOK, "%s", got.Name)
+		}
+	})
+
+	w := performRequest(e, http.MethodPost, "/", gzipped(`{"name":"lamp"}`), "Content-Type", MIMEJSON, "Content-Encoding", "gzip")
+	if w.Code != http.StatusOK || w.Body.String() != "lamp" {
+		t.Errorf("gzip: got %d %q", w.Code, w.Body)
+	}
+
+	// a few KiB compressed, a MiB decompressed
+	bomb := gzipped(`{"name":"` + strings.Repeat("a", 1<<20) + `"}`)
+	w = performRequest(e, http.MethodPost, "/", bomb, "Content-Type", MIMEJSON, "Content-Encoding", "gzip")
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("bomb: got %d %q", w.Code, w.Body)
+	}
+
+	w = performRequest(e, http.MethodPost, "/", strings.NewReader("x"), "Content-Type", MIMEJSON, "Content-Encoding", "br")
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("br: got %d", w.Code)
+	}
+}
@@ -0,0 +1,43 @@
+package gen
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// Error is an error attached to the Context by a handler or middleware
+type Error struct {
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// BindError is the panic of MustBindJSON, which Recovery answers with 400 instead of 500
+type BindError struct {
+	Err error
+}
+
+func (e *BindError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// isConnError reports whether err comes from a client that went away, which is not worth a panic
+func isConnError(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, http.ErrHijacked) ||
+		errors.Is(err, http.ErrHandlerTimeout)
+}
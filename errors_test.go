@@ -0,0 +1,40 @@
+package gen
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+)
+
+// failingWriter is a ResponseRecorder whose body writes fail with err, like a closed connection
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	err error
+}
+
+func (w *failingWriter) Write(data []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestWriteToClosedConnection(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var errs []error
+	e.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, H{"ok": true})
+		for _, err := range c.Errors {
+			errs = append(errs, err.Err)
+		}
+	})
+
+	w := &failingWriter{httptest.NewRecorder(), syscall.EPIPE}
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(errs) != 1 || !errors.Is(errs[0], syscall.EPIPE) {
+		t.Errorf("errors = %v, want EPIPE", errs)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want the 200 already sent", w.Code)
+	}
+}
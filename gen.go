@@ -3,28 +3,107 @@ package gen
 import (
 	"html/template"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
-	"github.com/quic-go/quic-go/http3"
 )
 
 type HandlerFunc func(c *Context)
 
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Handler     string // name of the main handler
+	HandlerFunc HandlerFunc
+	Name        string
+	Summary     string
+	Tags        []string
+}
+
+// CookieDefaults are the attributes of the cookies set by Context.SetCookie, unless given
+type CookieDefaults struct {
+	Path     string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
 type Engine struct {
 	*RouterGroup
 	router *httprouter.Router
 	groups []*RouterGroup // stores all groups
+	routes []*RouteInfo   // in registration order
+	// named routes' paths by name
+	namedRoutes map[string]string
+	noRoute     []HandlerFunc
+	noMethod    []HandlerFunc
+	final       []HandlerFunc  // set by UseFinal
+	errorPages  map[int]string // template names by status code
+	// set by DefaultHeaders
+	defaultHeaders http.Header
+	trustedCIDRs   []*net.IPNet
 	// for html render
 	htmlTemplates *template.Template
 	funcMap       template.FuncMap
+	// for Context.T, messages by language then message ID
+	messages        map[string]map[string]string
+	defaultLanguage string
+
+	// MaxHeaderBytes caps the request header size the server reads, see http.Server
+	MaxHeaderBytes int
+	// ReadTimeout, ReadHeaderTimeout, WriteTimeout and IdleTimeout configure the server of
+	// Run and RunTLS, see http.Server. Setting them guards against slow clients holding
+	// connections, like slowloris attacks. genquic only applies IdleTimeout.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// MaxRawDataSize caps the body read by Context.GetRawData, 10 MiB if zero
+	MaxRawDataSize int64
+	// CleanPath normalizes the request path before routing, collapsing duplicate slashes
+	// and resolving . and .. segments
+	CleanPath bool
+	// CleanPathRedirect makes CleanPath redirect to the clean path with 301 instead of rewriting it
+	CleanPathRedirect bool
+	// CaseInsensitive lowercases the request path before routing, so /Users matches /users.
+	// Routes must then be registered in lowercase, and the values of params are lowercased too;
+	// httprouter's own case-insensitive fallback (RedirectFixedPath) keeps them but only redirects.
+	CaseInsensitive bool
+	// CaseInsensitiveRedirect makes CaseInsensitive redirect to the lowercase path with 301
+	// instead of rewriting it, so clients and caches see a single canonical URL
+	CaseInsensitiveRedirect bool
+	// MethodOverride lets POST requests, like HTML forms, be routed as PUT, PATCH or DELETE
+	// by the X-HTTP-Method-Override header or the _method field of urlencoded forms
+	MethodOverride bool
+	// RenderErrorHandler handles the failures of rendering like JSON and HTML, other than
+	// the client going away. By default the error is logged and a 500 written if nothing was.
+	RenderErrorHandler func(c *Context, err error)
+	// AllowHeader builds the Allow header of 405 responses from the methods registered for
+	// the path, sorted and including OPTIONS. By default they're joined with ", ".
+	AllowHeader func(methods []string) string
+	// CookieDefaults are applied by Context.SetCookie to the attributes left zero
+	CookieDefaults CookieDefaults
+	// ErrorEnvelope builds the body of Context.FailJSON, overriding the default shape
+	ErrorEnvelope func(code int, message string, details []any) any
+
+	errorHandler func(c *Context, errs []*Error)
+	onPanic      func(c *Context, recovered any, stack []byte)
 }
 
 func New() *Engine {
 	engine := &Engine{router: httprouter.New()}
 	engine.RouterGroup = &RouterGroup{engine: engine}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
+	engine.router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		engine.handle(w, req, nil, nil, engine.noRouteHandlers())
+	})
+	engine.router.MethodNotAllowed = http.HandlerFunc(engine.methodNotAllowed)
 	log.SetPrefix("[GEN] ")
+	debugPrint("Running in %q mode. Switch to release mode in production: gen.SetMode(gen.ReleaseMode)\n", DebugMode)
 	return engine
 }
 
@@ -40,29 +119,245 @@ func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
 	e.funcMap = funcMap
 }
 
+// funcs returns the template functions, the builtin "url" of Reverse plus the ones of SetFuncMap
+func (e *Engine) funcs() template.FuncMap {
+	funcs := template.FuncMap{"url": e.Reverse}
+	for name, fn := range e.funcMap {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
 func (e *Engine) LoadHTMLGlob(path string) {
-	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcMap).ParseGlob(path)) // like *.tmpl
+	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcs()).ParseGlob(path)) // like *.tmpl
+	debugPrint("Loaded HTML templates (%d)\n", len(e.htmlTemplates.Templates()))
 }
 
 func (e *Engine) LoadHTMLFiles(files ...string) {
-	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcMap).ParseFiles(files...)) // like a.tmpl, b.tmpl...
+	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcs()).ParseFiles(files...)) // like a.tmpl, b.tmpl...
+	debugPrint("Loaded HTML templates (%d)\n", len(e.htmlTemplates.Templates()))
 }
 
-func (e *Engine) Run(addr string) error {
-	log.Printf("Listening and serving HTTP on %s\n", addr)
-	return http.ListenAndServe(addr, e)
+// ErrorHandler sets fn to turn the errors attached by Context.Error into a response, after the chain.
+// It's skipped if a response was already written.
+func (e *Engine) ErrorHandler(fn func(c *Context, errs []*Error)) {
+	e.errorHandler = fn
 }
 
-func (e *Engine) RunTLS(addr, certFile, keyFile string) error {
-	log.Printf("Listening and serving HTTPS on %s\n", addr)
-	return http.ListenAndServeTLS(addr, certFile, keyFile, e)
+// UseFinal adds middlewares running after the whole chain, even if it aborts or panics,
+// unlike those of Use, e.g. to finalize metrics. They run in order, after the error handler
+// and before the functions of Context.Defer, and shouldn't call Context.Next.
+func (e *Engine) UseFinal(middlewares ...HandlerFunc) {
+	e.final = append(e.final, middlewares...)
+}
+
+// OnPanic sets a hook called by Recovery with the recovered value and the stack of panics,
+// before the 500 is written, e.g. to report them to an error tracker
+func (e *Engine) OnPanic(hook func(c *Context, recovered any, stack []byte)) {
+	e.onPanic = hook
+}
+
+// DefaultHeaders sets headers added to every response before any handler runs,
+// like X-Powered-By, which handlers can still override
+func (e *Engine) DefaultHeaders(headers map[string]string) {
+	e.defaultHeaders = make(http.Header, len(headers))
+	for k, v := range headers {
+		e.defaultHeaders.Set(k, v)
+	}
+}
+
+func (e *Engine) setDefaultHeaders(c *Context) {
+	header := c.Writer.Header()
+	for k, v := range e.defaultHeaders {
+		header[k] = append([]string(nil), v...)
+	}
+}
+
+// Routes returns the registered routes in registration order
+func (e *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(e.routes))
+	for i, r := range e.routes {
+		routes[i] = *r
+		routes[i].Tags = append([]string(nil), r.Tags...)
+	}
+	return routes
+}
+
+// ExposeRoutes serves the route table as JSON at path, opt-in for development.
+// Handlers like BasicAuth can be passed to protect it.
+func (e *Engine) ExposeRoutes(path string, handlers ...HandlerFunc) {
+	handlers = append(handlers, func(c *Context) {
+		routes := e.Routes()
+		list := make([]H, len(routes))
+		for i, r := range routes {
+			list[i] = H{"method": r.Method, "path": r.Path, "handler": r.Handler}
+		}
+		c.JSON(http.StatusOK, list)
+	})
+	e.GET(path, handlers...)
+}
+
+// NoRoute sets the handlers for requests matching no route, ending with a 404 unless they abort.
+func (e *Engine) NoRoute(handlers ...HandlerFunc) {
+	e.noRoute = append(handlers, notFound)
+}
+
+func notFound(c *Context) {
+	c.errorPage(http.StatusNotFound, "404 page not found")
 }
 
-func (e *Engine) RunQUIC(addr, certFile, keyFile string) error {
-	log.Printf("Listening and serving HTTP3 on %s\n", addr)
-	return http3.ListenAndServeTLS(addr, certFile, keyFile, e)
+// NoMethod sets the handlers for requests whose path matches routes of other methods only,
+// ending with a 405 unless they abort. The Allow header is already set when they run.
+func (e *Engine) NoMethod(handlers ...HandlerFunc) {
+	e.noMethod = append(handlers, notAllowed)
+}
+
+func notAllowed(c *Context) {
+	c.errorPage(http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+}
+
+// methodNotAllowed is called by the router with the Allow header set
+func (e *Engine) methodNotAllowed(w http.ResponseWriter, req *http.Request) {
+	if e.AllowHeader != nil {
+		methods := strings.Split(w.Header().Get("Allow"), ", ")
+		w.Header().Set("Allow", e.AllowHeader(methods))
+	}
+	handlers := e.noMethod
+	if handlers == nil {
+		handlers = []HandlerFunc{notAllowed}
+	}
+	e.handle(w, req, nil, nil, handlers)
+}
+
+// SetErrorPages maps status codes to the HTML templates rendered for them by NoRoute,
+// NoMethod and Recovery to clients accepting HTML. The templates get H{"code": ..., "message": ...}.
+func (e *Engine) SetErrorPages(pages map[int]string) {
+	e.errorPages = pages
+}
+
+// noRouteHandlers returns the handlers of NoRoute, or a plain 404 if unset
+func (e *Engine) noRouteHandlers() []HandlerFunc {
+	if e.noRoute == nil {
+		return []HandlerFunc{notFound}
+	}
+	return e.noRoute
+}
+
+// NoRouteServeFile serves indexPath on unmatched GET requests accepting HTML, so that
+// client-side routing of single-page apps works. If fallback is non-nil, only the
+// requests it approves get the file, e.g. to keep 404 for API paths.
+func (e *Engine) NoRouteServeFile(indexPath string, fallback func(c *Context) bool) {
+	e.NoRoute(func(c *Context) {
+		if c.Method != http.MethodGet && c.Method != http.MethodHead {
+			return
+		}
+		if !strings.Contains(c.Request.Header.Get("Accept"), MIMEHTML) {
+			return
+		}
+		if fallback != nil && !fallback(c) {
+			return
+		}
+		c.File(indexPath)
+		c.Abort()
+	})
+}
+
+// handle runs the middlewares of the groups matching the request path, then handlers
+// of route, nil for NoRoute and NoMethod
+func (e *Engine) handle(w http.ResponseWriter, req *http.Request, params httprouter.Params, route *RouteInfo, handlers []HandlerFunc) {
+	var chain []HandlerFunc
+	for _, group := range e.groups {
+		if strings.HasPrefix(req.URL.Path, group.prefix) {
+			chain = append(chain, group.middlewares...)
+		}
+	}
+	e.serve(w, req, params, route, append(chain, handlers...))
+}
+
+// serve runs the chain of handlers for the request, after the default headers, then the
+// error handler and final middlewares
+func (e *Engine) serve(w http.ResponseWriter, req *http.Request, params httprouter.Params, route *RouteInfo, handlers []HandlerFunc) {
+	c := newContext(w, req, params)
+	c.engine = e
+	c.route = route
+	if e.defaultHeaders != nil {
+		c.handlers = append(c.handlers, e.setDefaultHeaders)
+	}
+	c.handlers = append(c.handlers, handlers...)
+	defer c.runDeferred()
+	if e.final != nil {
+		defer func() {
+			for _, h := range e.final {
+				h(c)
+			}
+		}()
+	}
+	c.Next()
+	if e.errorHandler != nil && len(c.Errors) > 0 && !c.Writer.Written() {
+		e.errorHandler(c, c.Errors)
+	}
+}
+
+func (e *Engine) server(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           e,
+		MaxHeaderBytes:    e.MaxHeaderBytes,
+		ReadTimeout:       e.ReadTimeout,
+		ReadHeaderTimeout: e.ReadHeaderTimeout,
+		WriteTimeout:      e.WriteTimeout,
+		IdleTimeout:       e.IdleTimeout,
+	}
+}
+
+func (e *Engine) Run(addr string) error {
+	debugPrint("Listening and serving HTTP on %s\n", addr)
+	return e.server(addr).ListenAndServe()
+}
+
+func (e *Engine) RunTLS(addr, certFile, keyFile string) error {
+	debugPrint("Listening and serving HTTPS on %s\n", addr)
+	return e.server(addr).ListenAndServeTLS(certFile, keyFile)
 }
 
 func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if e.CleanPath {
+		if p := httprouter.CleanPath(req.URL.Path); p != req.URL.Path {
+			if e.CleanPathRedirect {
+				u := *req.URL
+				u.Path, u.RawPath = p, ""
+				http.Redirect(w, req, u.RequestURI(), http.StatusMovedPermanently)
+				return
+			}
+			req.URL.Path, req.URL.RawPath = p, ""
+		}
+	}
+	if e.CaseInsensitive {
+		if p := strings.ToLower(req.URL.Path); p != req.URL.Path {
+			if e.CaseInsensitiveRedirect {
+				u := *req.URL
+				u.Path, u.RawPath = p, ""
+				http.Redirect(w, req, u.RequestURI(), http.StatusMovedPermanently)
+				return
+			}
+			req.URL.Path, req.URL.RawPath = p, ""
+		}
+	}
+	if e.MethodOverride && req.Method == http.MethodPost {
+		overrideMethod(req)
+	}
 	e.router.ServeHTTP(w, req)
 }
+
+// overrideMethod reads _method from urlencoded forms only, since parsing a multipart body
+// would leave nothing for Request.MultipartReader, e.g. of streaming uploads
+func overrideMethod(req *http.Request) {
+	method := req.Header.Get("X-HTTP-Method-Override")
+	if method == "" && strings.HasPrefix(req.Header.Get("Content-Type"), MIMEPOSTForm) {
+		method = req.PostFormValue("_method")
+	}
+	switch method = strings.ToUpper(method); method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		req.Method = method
+	}
+}
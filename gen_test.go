@@ -0,0 +1,377 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotFoundErrorPages(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/", func(c *Context) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	r.Header.Set("Accept", MIMEJSON)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"404 page not found"}`+"\n" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 page not found" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHTMLErrorPages(t *testing.T) {
+	SetMode(TestMode)
+	dir := t.TempDir()
+	page := filepath.Join(dir, "error.html")
+	if err := os.WriteFile(page, []byte(`<h1>{{.code}}</h1><p>{{.message}}</p>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.Use(Recovery())
+	e.LoadHTMLFiles(page)
+	e.SetErrorPages(map[int]string{http.StatusNotFound: "error.html", http.StatusInternalServerError: "error.html"})
+	e.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	w := performRequest(e, http.MethodGet, "/missing", nil, "Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if w.Code != http.StatusNotFound || w.Body.String() != "<h1>404</h1><p>404 page not found</p>" {
+		t.Errorf("404: got %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, MIMEHTML) {
+		t.Errorf("404: Content-Type %q", ct)
+	}
+	w = performRequest(e, http.MethodGet, "/panic", nil, "Accept", "text/html")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "<h1>500</h1><p>Internal Server Error</p>" {
+		t.Errorf("500: got %d %q", w.Code, w.Body.String())
+	}
+	// clients not accepting HTML still get the plain page
+	w = performRequest(e, http.MethodGet, "/missing", nil)
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 page not found" {
+		t.Errorf("plain 404: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// performRequest serves a request to e, with headers given as key, value pairs
+func performRequest(e http.Handler, method, path string, body io.Reader, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, body)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	return w
+}
+
+func TestNoRouteServeFile(t *testing.T) {
+	SetMode(TestMode)
+	index := filepath.Join(t.TempDir(), "index.html")
+	if err := os.WriteFile(index, []byte("<html>app</html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.NoRouteServeFile(index, func(c *Context) bool {
+		return !strings.HasPrefix(c.Path, "/api/")
+	})
+
+	w := performRequest(e, http.MethodGet, "/app/foo", nil, "Accept", "text/html,*/*")
+	if w.Code != http.StatusOK || w.Body.String() != "<html>app</html>" {
+		t.Errorf("/app/foo: got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodGet, "/api/unknown", nil, "Accept", "text/html,*/*")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("/api/unknown: got %d, want 404", w.Code)
+	}
+	w = performRequest(e, http.MethodGet, "/app/foo", nil, "Accept", MIMEJSON)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("/app/foo as JSON: got %d, want 404", w.Code)
+	}
+}
+
+func listUsers(c *Context) {}
+
+func TestRoutes(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/users", listUsers)
+	e.Group("/admin").POST("/users/:id", func(c *Context) {})
+
+	routes := e.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want 2", len(routes))
+	}
+	if r := routes[0]; r.Method != http.MethodGet || r.Path != "/users" || !strings.HasSuffix(r.Handler, ".listUsers") || r.HandlerFunc == nil {
+		t.Errorf("routes[0] = %+v", r)
+	}
+	if r := routes[1]; r.Method != http.MethodPost || r.Path != "/admin/users/:id" {
+		t.Errorf("routes[1] = %+v", r)
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.CleanPath = true
+	e.GET("/a/c", func(c *Context) {
+		c.String(http.StatusOK, c.Request.URL.Path)
+	})
+
+	w := performRequest(e, http.MethodGet, "//a/./b/../c", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "/a/c" {
+		t.Errorf("rewrite: got %d %q", w.Code, w.Body.String())
+	}
+
+	e.CleanPathRedirect = true
+	w = performRequest(e, http.MethodGet, "//a/./b/../c?x=1", nil)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/a/c?x=1" {
+		t.Errorf("redirect: got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.MethodOverride = true
+	e.DELETE("/items/1", func(c *Context) {
+		c.String(http.StatusOK, "deleted")
+	})
+	e.PUT("/items/1", func(c *Context) {
+		c.String(http.StatusOK, "put "+c.PostForm("name"))
+	})
+
+	w := performRequest(e, http.MethodPost, "/items/1", nil, "X-HTTP-Method-Override", "DELETE")
+	if w.Body.String() != "deleted" {
+		t.Errorf("header override: got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodPost, "/items/1", strings.NewReader("_method=put&name=box"), "Content-Type", MIMEPOSTForm)
+	if w.Body.String() != "put box" {
+		t.Errorf("form override: got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodPost, "/items/1", nil, "X-HTTP-Method-Override", "GET")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("override to GET: got %d, want 405", w.Code)
+	}
+}
+
+func TestMethodOverrideKeepsMultipartStreamable(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.MethodOverride = true
+	e.PUT("/files", func(c *Context) {
+		c.String(http.StatusOK, "put")
+	})
+	e.POST("/files", func(c *Context) {
+		mr, err := c.Request.MultipartReader()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "%v", err)
+			return
+		}
+		part, err := mr.NextPart()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "%v", err)
+			return
+		}
+		data, _ := io.ReadAll(part)
+		c.String(http.StatusOK, "%s", data)
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("_method", "PUT")
+	mw.Close()
+	w := performRequest(e, http.MethodPost, "/files", &body, "Content-Type", mw.FormDataContentType())
+	if w.Code != http.StatusOK || w.Body.String() != "PUT" {
+		t.Errorf("multipart: got %d %q, want the POST handler streaming the parts", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodPost, "/files", nil, "X-HTTP-Method-Override", "PUT")
+	if w.Body.String() != "put" {
+		t.Errorf("header override: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	calls := 0
+	e.ErrorHandler(func(c *Context, errs []*Error) {
+		calls++
+		c.JSON(http.StatusBadGateway, H{"errors": len(errs)})
+	})
+	e.GET("/failed", func(c *Context) {
+		c.Error(errors.New("upstream down"))
+	})
+	e.GET("/ok", func(c *Context) {
+		c.String(http.StatusOK, "fine")
+	})
+	e.GET("/written", func(c *Context) {
+		c.Error(errors.New("logged only"))
+		c.String(http.StatusAccepted, "already answered")
+	})
+
+	w := performRequest(e, http.MethodGet, "/failed", nil)
+	if w.Code != http.StatusBadGateway || w.Body.String() != `{"errors":1}`+"\n" || calls != 1 {
+		t.Errorf("with errors: got %d %q after %d calls", w.Code, w.Body.String(), calls)
+	}
+	w = performRequest(e, http.MethodGet, "/ok", nil)
+	if w.Body.String() != "fine" || calls != 1 {
+		t.Errorf("without errors: got %q after %d calls", w.Body.String(), calls)
+	}
+	w = performRequest(e, http.MethodGet, "/written", nil)
+	if w.Code != http.StatusAccepted || w.Body.String() != "already answered" || calls != 1 {
+		t.Errorf("already written: got %d %q after %d calls", w.Code, w.Body.String(), calls)
+	}
+}
+
+func TestExposeRoutes(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/users", listUsers)
+	e.ExposeRoutes("/debug/routes")
+
+	w := performRequest(e, http.MethodGet, "/debug/routes", nil)
+	var routes []struct {
+		Method  string `json:"method"`
+		Path    string `json:"path"`
+		Handler string `json:"handler"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("%v in %q", err, w.Body.String())
+	}
+	if len(routes) != 2 || routes[0].Path != "/users" || !strings.HasSuffix(routes[0].Handler, ".listUsers") || routes[1].Path != "/debug/routes" {
+		t.Errorf("routes = %+v", routes)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.CaseInsensitive = true
+	e.GET("/users/:name", func(c *Context) {
+		c.String(http.StatusOK, c.Param("name"))
+	})
+
+	w := performRequest(e, http.MethodGet, "/Users/Ann", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "ann" {
+		t.Errorf("rewrite: got %d %q", w.Code, w.Body.String())
+	}
+
+	e.CaseInsensitiveRedirect = true
+	w = performRequest(e, http.MethodGet, "/USERS/ann?Q=1", nil)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/users/ann?Q=1" {
+		t.Errorf("redirect: got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.DefaultHeaders(map[string]string{"X-Frame-Options": "DENY", "Cache-Control": "no-store"})
+	e.GET("/", func(c *Context) {})
+	e.GET("/cached", func(c *Context) {
+		c.SetHeader("Cache-Control", "max-age=60")
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Header().Get("X-Frame-Options") != "DENY" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("defaults: %v", w.Header())
+	}
+	w = performRequest(e, http.MethodGet, "/cached", nil)
+	if got := w.Header().Values("Cache-Control"); len(got) != 1 || got[0] != "max-age=60" {
+		t.Errorf("overridden Cache-Control = %q", got)
+	}
+	if w = performRequest(e, http.MethodGet, "/missing", nil); w.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("404 headers: %v", w.Header())
+	}
+}
+
+func TestAllowHeader(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	nop := func(c *Context) {}
+	e.PUT("/items", nop)
+	e.GET("/items", nop)
+	e.DELETE("/items", nop)
+	e.PATCH("/items", nop)
+
+	for i := 0; i < 10; i++ {
+		w := performRequest(e, http.MethodPost, "/items", nil)
+		if got := w.Header().Get("Allow"); w.Code != http.StatusMethodNotAllowed || got != "DELETE, GET, OPTIONS, PATCH, PUT" {
+			t.Fatalf("got %d with Allow %q", w.Code, got)
+		}
+	}
+
+	e.AllowHeader = func(methods []string) string {
+		return strings.Join(methods, ",") + ",HEAD"
+	}
+	w := performRequest(e, http.MethodPost, "/items", nil)
+	if got := w.Header().Get("Allow"); got != "DELETE,GET,OPTIONS,PATCH,PUT,HEAD" {
+		t.Errorf("AllowHeader hook: Allow = %q", got)
+	}
+}
+
+func TestUseFinal(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(Recovery())
+	var statuses []int
+	e.UseFinal(func(c *Context) {
+		statuses = append(statuses, c.Writer.Status())
+	})
+	e.GET("/ok", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	e.GET("/abort", func(c *Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}, func(c *Context) {})
+	e.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	for _, path := range []string{"/ok", "/abort", "/panic"} {
+		performRequest(e, http.MethodGet, path, nil)
+	}
+	if fmt.Sprint(statuses) != "[200 401 500]" {
+		t.Errorf("final middlewares saw %v", statuses)
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	e := New()
+	e.ReadTimeout = 5 * time.Second
+	e.ReadHeaderTimeout = 2 * time.Second
+	e.WriteTimeout = 10 * time.Second
+	e.IdleTimeout = time.Minute
+	e.MaxHeaderBytes = 1 << 14
+
+	srv := e.server(":8080")
+	if srv.Addr != ":8080" || srv.Handler != e {
+		t.Errorf("got Addr %q, Handler %v", srv.Addr, srv.Handler)
+	}
+	if srv.ReadTimeout != 5*time.Second || srv.ReadHeaderTimeout != 2*time.Second ||
+		srv.WriteTimeout != 10*time.Second || srv.IdleTimeout != time.Minute || srv.MaxHeaderBytes != 1<<14 {
+		t.Errorf("got read %v, read header %v, write %v, idle %v, max header %d",
+			srv.ReadTimeout, srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout, srv.MaxHeaderBytes)
+	}
+}
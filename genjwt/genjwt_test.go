@@ -0,0 +1,61 @@
+package genjwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EndlessParadox1/gen"
+)
+
+// sign builds an HS256 token of claims signed by secret
+func sign(t *testing.T, claims map[string]any, secret string) string {
+	header, _ := json.Marshal(map[string]any{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWT(t *testing.T) {
+	gen.SetMode(gen.TestMode)
+	e := gen.New()
+	e.Use(JWT(JWTConfig{Key: []byte("secret")}))
+	e.GET("/me", func(c *gen.Context) {
+		c.String(http.StatusOK, c.MustGet(gen.AuthUserKey).(string))
+	})
+	get := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		return w
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	if w := get(sign(t, map[string]any{"sub": "ann", "exp": exp}, "secret")); w.Code != http.StatusOK || w.Body.String() != "ann" {
+		t.Errorf("valid: got %d %q", w.Code, w.Body.String())
+	}
+	w := get(sign(t, map[string]any{"sub": "ann", "exp": time.Now().Add(-time.Hour).Unix()}, "secret"))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), ErrExpired.Error()) {
+		t.Errorf("expired: got %d %q", w.Code, w.Body.String())
+	}
+	w = get(sign(t, map[string]any{"sub": "ann", "exp": exp}, "guessed"))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), ErrSignature.Error()) || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("wrong signature: got %d %q", w.Code, w.Body.String())
+	}
+	if _, err := Parse("not.a.jwt", JWTConfig{Key: []byte("secret")}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("malformed: err = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+// Package genjwt authenticates gen requests by JSON Web Tokens in the Authorization header.
+// It verifies the HS256/384/512, RS256/384/512 and ES256/384/512 algorithms.
+package genjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EndlessParadox1/gen"
+)
+
+var (
+	ErrMalformed       = errors.New("genjwt: malformed token")
+	ErrSignature       = errors.New("genjwt: invalid signature")
+	ErrUnsupportedAlg  = errors.New("genjwt: unsupported algorithm")
+	ErrExpired         = errors.New("genjwt: token is expired")
+	ErrNotValidYet     = errors.New("genjwt: token is not valid yet")
+	ErrInvalidIssuer   = errors.New("genjwt: invalid issuer")
+	ErrInvalidAudience = errors.New("genjwt: invalid audience")
+	ErrMissingToken    = errors.New("genjwt: missing bearer token")
+	errKeyTypeMismatch = errors.New("genjwt: key type doesn't match the algorithm")
+)
+
+type JWTConfig struct {
+	// Key verifies the tokens: a []byte secret for HS*, *rsa.PublicKey for RS*
+	// and *ecdsa.PublicKey for ES*
+	Key any
+	// KeyFunc returns the key by the token header, e.g. by its "kid", overriding Key
+	KeyFunc func(header map[string]any) (any, error)
+	// Algorithms are the accepted "alg" values, all the supported ones by default.
+	// Restrict them when the key comes from KeyFunc.
+	Algorithms []string
+	// Issuer and Audience, if set, must match the "iss" and "aud" claims
+	Issuer   string
+	Audience string
+	// Leeway is the clock skew tolerated checking "exp" and "nbf"
+	Leeway time.Duration
+}
+
+// JWT verifies the bearer token of requests and stores its claims under gen.ClaimsKey,
+// see Context.Claims, and the "sub" claim under gen.AuthUserKey. Requests without
+// a valid token are aborted with 401.
+func JWT(config JWTConfig) gen.HandlerFunc {
+	return func(c *gen.Context) {
+		claims, err := verify(c, config)
+		if err != nil {
+			c.SetHeader("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gen.H{"error": err.Error()})
+			return
+		}
+		c.Set(gen.ClaimsKey, claims)
+		if sub, ok := claims["sub"].(string); ok {
+			c.Set(gen.AuthUserKey, sub)
+		}
+	}
+}
+
+func verify(c *gen.Context, config JWTConfig) (map[string]any, error) {
+	token, ok := c.BearerToken()
+	if !ok {
+		return nil, ErrMissingToken
+	}
+	return Parse(token, config)
+}
+
+// Parse verifies token and its standard claims by config, returning the claims
+func Parse(token string, config JWTConfig) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var header map[string]any
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	alg, _ := header["alg"].(string)
+	if !allowed(alg, config.Algorithms) {
+		return nil, ErrUnsupportedAlg
+	}
+	key := config.Key
+	if config.KeyFunc != nil {
+		var err error
+		if key, err = config.KeyFunc(header); err != nil {
+			return nil, err
+		}
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err := verifySignature(alg, parts[0]+"."+parts[1], sig, key); err != nil {
+		return nil, err
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, checkClaims(claims, config)
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+var hashes = map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+
+func allowed(alg string, algorithms []string) bool {
+	if len(alg) != 5 || hashes[alg[2:]] == 0 {
+		return false
+	}
+	switch alg[:2] {
+	case "HS", "RS", "ES":
+	default:
+		return false
+	}
+	if len(algorithms) == 0 {
+		return true
+	}
+	for _, a := range algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func verifySignature(alg, signed string, sig []byte, key any) error {
+	hash := hashes[alg[2:]]
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return errKeyTypeMismatch
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrSignature
+		}
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errKeyTypeMismatch
+		}
+		if rsa.VerifyPKCS1v15(pub, hash, digest, sig) != nil {
+			return ErrSignature
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errKeyTypeMismatch
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrSignature
+		}
+	}
+	return nil
+}
+
+func checkClaims(claims map[string]any, config JWTConfig) error {
+	now := time.Now()
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(config.Leeway)) {
+		return ErrExpired
+	}
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(config.Leeway).Before(nbf) {
+		return ErrNotValidYet
+	}
+	if config.Issuer != "" && claims["iss"] != config.Issuer {
+		return ErrInvalidIssuer
+	}
+	if config.Audience != "" && !hasAudience(claims["aud"], config.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func numericDate(claims map[string]any, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("genjwt: claim %q is not a number", name)
+	}
+	return time.Unix(int64(n), 0), true, nil
+}
+
+// hasAudience reports whether the "aud" claim, a string or an array of strings, includes audience
+func hasAudience(aud any, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
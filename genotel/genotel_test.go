@@ -0,0 +1,93 @@
+package genotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EndlessParadox1/gen"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recorder is a TracerProvider keeping the spans started by its tracers
+type recorder struct {
+	embedded.TracerProvider
+	spans []*recordedSpan
+}
+
+func (p *recorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{p: p}
+}
+
+type recordingTracer struct {
+	embedded.Tracer
+	p *recorder
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	s := &recordedSpan{name: name, kind: cfg.SpanKind(), attrs: map[attribute.Key]attribute.Value{}}
+	for _, kv := range cfg.Attributes() {
+		s.attrs[kv.Key] = kv.Value
+	}
+	t.p.spans = append(t.p.spans, s)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+type recordedSpan struct {
+	noop.Span
+	name   string
+	kind   trace.SpanKind
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	ended  bool
+}
+
+func (s *recordedSpan) IsRecording() bool { return !s.ended }
+
+func (s *recordedSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *recordedSpan) SetAttributes(kvs ...attribute.KeyValue) {
+	for _, kv := range kvs {
+		s.attrs[kv.Key] = kv.Value
+	}
+}
+
+func TestOtel(t *testing.T) {
+	gen.SetMode(gen.TestMode)
+	rec := &recorder{}
+	e := gen.New()
+	e.Use(Otel(WithTracerProvider(rec)))
+	var inContext bool
+	e.GET("/users/:id", func(c *gen.Context) {
+		inContext = trace.SpanFromContext(c.Request.Context()) == trace.Span(rec.spans[0])
+		c.String(http.StatusOK, "ok")
+	})
+	e.GET("/fail", func(c *gen.Context) {
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(rec.spans))
+	}
+	ok, failed := rec.spans[0], rec.spans[1]
+	if ok.name != "/users/:id" || ok.kind != trace.SpanKindServer || !ok.ended || !inContext {
+		t.Errorf("span %q kind %v ended %v in context %v", ok.name, ok.kind, ok.ended, inContext)
+	}
+	if ok.attrs["http.response.status_code"].AsInt64() != http.StatusOK || ok.attrs["url.path"].AsString() != "/users/7" || ok.status != codes.Unset {
+		t.Errorf("span attributes %v, status %v", ok.attrs, ok.status)
+	}
+	if failed.attrs["http.response.status_code"].AsInt64() != http.StatusServiceUnavailable || failed.status != codes.Error || !failed.ended {
+		t.Errorf("failed span attributes %v, status %v", failed.attrs, failed.status)
+	}
+}
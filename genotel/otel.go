@@ -0,0 +1,86 @@
+// Package genotel traces gen requests with OpenTelemetry: the Otel middleware starts a
+// server span per request, and handlers reach it through the request's context.Context,
+// e.g. to start child spans with trace.SpanFromContext.
+package genotel
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/EndlessParadox1/gen"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/EndlessParadox1/gen/genotel"
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+}
+
+type Option func(*config)
+
+// WithTracerProvider sets the provider of the tracer, the global one by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(cfg *config) {
+		cfg.tracerProvider = provider
+	}
+}
+
+// WithPropagators sets the propagators extracting the incoming trace context,
+// the global ones by default
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(cfg *config) {
+		cfg.propagators = propagators
+	}
+}
+
+// Otel starts a server span per request named by the route pattern, continuing the incoming
+// trace context, and injects it into the request's context.Context for the handlers.
+// The method, route, status and latency are recorded, 5xx statuses mark the span as failed.
+func Otel(options ...Option) gen.HandlerFunc {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+	tracer := cfg.tracerProvider.Tracer(tracerName)
+	return func(c *gen.Context) {
+		ctx := cfg.propagators.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := route
+		if name == "" {
+			name = "HTTP " + c.Method
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Path),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+		status := c.Writer.Status()
+		span.SetAttributes(
+			attribute.Int("http.response.status_code", status),
+			attribute.Float64("http.server.request.duration", time.Since(start).Seconds()),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Package genquic serves a gen.Engine over HTTP/3. It's a module of its own,
+// so that only its users depend on quic-go. It replaces Engine.RunQUIC:
+// e.RunQUIC(addr, cert, key) becomes genquic.Run(e, addr, cert, key).
+package genquic
+
+import (
+	"log"
+
+	"github.com/EndlessParadox1/gen"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Server returns an HTTP/3 server of e on addr, with the engine's MaxHeaderBytes and
+// IdleTimeout, the other timeouts of Engine having no counterpart in QUIC
+func Server(e *gen.Engine, addr string) *http3.Server {
+	server := &http3.Server{
+		Addr:           addr,
+		Handler:        e,
+		MaxHeaderBytes: e.MaxHeaderBytes,
+	}
+	if e.IdleTimeout > 0 {
+		server.QUICConfig = &quic.Config{MaxIdleTimeout: e.IdleTimeout}
+	}
+	return server
+}
+
+// Run serves HTTP/3 over QUIC with the same handler as Engine.Run
+func Run(e *gen.Engine, addr, certFile, keyFile string) error {
+	if gen.IsDebugging() {
+		log.Printf("[debug] Listening and serving HTTP3 on %s\n", addr)
+	}
+	return Server(e, addr).ListenAndServeTLS(certFile, keyFile)
+}
@@ -0,0 +1,69 @@
+package genquic
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EndlessParadox1/gen"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestServerHTTP3(t *testing.T) {
+	if testing.Short() {
+		t.Skip("serves over UDP")
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no UDP: %v", err)
+	}
+
+	gen.SetMode(gen.TestMode)
+	e := gen.New()
+	e.GET("/ping", func(c *gen.Context) {
+		c.String(http.StatusOK, "pong over %s", c.Request.Proto)
+	})
+	server := Server(e, conn.LocalAddr().String())
+	server.TLSConfig = http3.ConfigureTLSConfig(&tls.Config{Certificates: []tls.Certificate{testCert(t)}})
+	go server.Serve(conn)
+	defer server.Close()
+
+	rt := &http3.RoundTripper{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	defer rt.Close()
+	client := &http.Client{Transport: rt, Timeout: 5 * time.Second}
+	resp, err := client.Get("https://" + conn.LocalAddr().String() + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "pong over HTTP/3.0" {
+		t.Errorf("got %d %q", resp.StatusCode, body)
+	}
+}
+
+func testCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,48 @@
+package gen
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ForceHTTPSConfig struct {
+	// Temporary redirects with 307 instead of 308
+	Temporary bool
+	// HSTSMaxAge adds Strict-Transport-Security to HTTPS responses if positive
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+}
+
+// ForceHTTPS redirects HTTP requests to their HTTPS equivalent with 308. Behind a proxy
+// terminating TLS, set it with Engine.SetTrustedProxies to avoid redirect loops.
+func ForceHTTPS() HandlerFunc {
+	return ForceHTTPSWithConfig(ForceHTTPSConfig{})
+}
+
+func ForceHTTPSWithConfig(config ForceHTTPSConfig) HandlerFunc {
+	code := http.StatusPermanentRedirect
+	if config.Temporary {
+		code = http.StatusTemporaryRedirect
+	}
+	hsts := ""
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(config.HSTSMaxAge/time.Second), 10)
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	return func(c *Context) {
+		if c.IsHTTPS() {
+			if hsts != "" {
+				c.SetHeader("Strict-Transport-Security", hsts)
+			}
+			return
+		}
+		u := *c.Request.URL
+		u.Scheme = "https"
+		u.Host = c.Request.Host
+		http.Redirect(c.Writer, c.Request, u.String(), code)
+		c.Abort()
+	}
+}
@@ -0,0 +1,26 @@
+package gen
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestForceHTTPS(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(ForceHTTPSWithConfig(ForceHTTPSConfig{HSTSMaxAge: time.Hour, HSTSIncludeSubdomains: true}))
+	e.GET("/pay", func(c *Context) {
+		c.String(http.StatusOK, "secure")
+	})
+
+	w := performRequest(e, http.MethodGet, "http://shop.example/pay?x=1", nil)
+	if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != "https://shop.example/pay?x=1" {
+		t.Errorf("http: got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = performRequest(e, http.MethodGet, "https://shop.example/pay", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "secure" || w.Header().Get("Strict-Transport-Security") != "max-age=3600; includeSubDomains" {
+		t.Errorf("https: got %d %q with HSTS %q", w.Code, w.Body.String(), w.Header().Get("Strict-Transport-Security"))
+	}
+}
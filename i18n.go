@@ -0,0 +1,92 @@
+package gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AddMessages adds the messages of lang to the catalog used by Context.T, keyed by message ID.
+// Other formats like TOML can be decoded by the caller and added this way.
+func (e *Engine) AddMessages(lang string, messages map[string]string) {
+	if e.messages == nil {
+		e.messages = make(map[string]map[string]string)
+	}
+	if e.messages[lang] == nil {
+		e.messages[lang] = make(map[string]string, len(messages))
+	}
+	for id, msg := range messages {
+		e.messages[lang][id] = msg
+	}
+}
+
+// LoadMessages adds the messages of lang from a JSON file like {"hello": "Hello, %s!"}
+func (e *Engine) LoadMessages(lang, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("i18n: %s: %w", file, err)
+	}
+	e.AddMessages(lang, messages)
+	return nil
+}
+
+// SetDefaultLanguage sets the language Context.T falls back to, "en" by default
+func (e *Engine) SetDefaultLanguage(lang string) {
+	e.defaultLanguage = lang
+}
+
+// T returns the message of messageID in the language negotiated by PreferredLanguage,
+// formatted with args if any. It falls back to the default language, then to messageID itself.
+func (c *Context) T(messageID string, args ...any) string {
+	e := c.engine
+	defaultLang := e.defaultLanguage
+	if defaultLang == "" {
+		defaultLang = "en"
+	}
+	langs := make([]string, 0, len(e.messages))
+	for lang := range e.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	msg, ok := e.messages[c.PreferredLanguage(langs...)][messageID]
+	if !ok {
+		msg, ok = e.messages[defaultLang][messageID]
+	}
+	if !ok {
+		msg = messageID
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// PreferredLanguage returns the language of Accept-Language with the highest quality among supported,
+// matching "en-US" to "en" too, or "" if none. Without supported, it returns the top language.
+func (c *Context) PreferredLanguage(supported ...string) string {
+	tags := parseAccept(c.Request.Header.Get("Accept-Language"))
+	if len(supported) == 0 {
+		if len(tags) == 0 {
+			return ""
+		}
+		return tags[0].value
+	}
+	for _, t := range tags {
+		if t.q <= 0 {
+			continue
+		}
+		base, _, _ := strings.Cut(t.value, "-")
+		for _, s := range supported {
+			if strings.EqualFold(s, t.value) || strings.EqualFold(s, base) {
+				return s
+			}
+		}
+	}
+	return ""
+}
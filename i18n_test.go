@@ -0,0 +1,34 @@
+package gen
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestT(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.AddMessages("en", map[string]string{"hello": "Hello, %s!", "bye": "Bye"})
+	e.AddMessages("fr", map[string]string{"hello": "Bonjour, %s !"})
+	e.GET("/:id", func(c *Context) {
+		if id := c.Param("id"); id == "hello" {
+			c.String(http.StatusOK, "%s", c.T(id, "Ann"))
+		} else {
+			c.String(http.StatusOK, "%s", c.T(id))
+		}
+	})
+
+	for _, tt := range []struct {
+		id, lang, want string
+	}{
+		{"hello", "fr-FR,en;q=0.5", "Bonjour, Ann !"},
+		{"hello", "de", "Hello, Ann!"},
+		{"bye", "fr", "Bye"},
+		{"missing", "fr", "missing"},
+	} {
+		w := performRequest(e, http.MethodGet, "/"+tt.id, nil, "Accept-Language", tt.lang)
+		if w.Body.String() != tt.want {
+			t.Errorf("T(%q) in %q = %q, want %q", tt.id, tt.lang, w.Body.String(), tt.want)
+		}
+	}
+}
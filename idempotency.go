@@ -0,0 +1,156 @@
+package gen
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// CachedResponse is a response recorded for replay
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore keeps the responses recorded by Idempotency for ttl
+type IdempotencyStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+}
+
+// Idempotency replays the first response of an unsafe request carrying an Idempotency-Key
+// to the duplicates within ttl, so e.g. a retried payment isn't charged twice.
+// Concurrent duplicates wait for the first one instead of running too.
+// Responses with 5xx statuses are not recorded, letting the client retry.
+func Idempotency(store IdempotencyStore, ttl time.Duration) HandlerFunc {
+	var locks keyedMutex
+	return func(c *Context) {
+		switch c.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			return
+		}
+		idempotencyKey := c.Request.Header.Get(HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			return
+		}
+		key := c.Method + " " + c.Path + " " + idempotencyKey
+		unlock := locks.lock(key)
+		defer unlock()
+		if resp, ok := store.Get(key); ok {
+			c.replay(resp)
+			return
+		}
+		w := &recordWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if status := c.Writer.Status(); status < http.StatusInternalServerError {
+			store.Set(key, &CachedResponse{
+				Status: status,
+				Header: c.Writer.Header().Clone(),
+				Body:   w.body.Bytes(),
+			}, ttl)
+		}
+	}
+}
+
+// replay writes resp and aborts
+func (c *Context) replay(resp *CachedResponse) {
+	header := c.Writer.Header()
+	for k, v := range resp.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	c.AbortWithStatus(resp.Status)
+	_, err := c.Writer.Write(resp.Body)
+	c.writeError(err)
+}
+
+// recordWriter tees the response body while writing it through
+type recordWriter struct {
+	ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// keyedMutex is a set of mutexes by key, dropped once unused
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (m *keyedMutex) lock(key string) (unlock func()) {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore in process memory
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	resp    *CachedResponse
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryIdempotencyStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return e.resp, true
+}
+
+func (s *MemoryIdempotencyStore) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, e := range s.entries { // sweep the expired ones along the way
+		if now.After(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryEntry{resp, now.Add(ttl)}
+}
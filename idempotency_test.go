@@ -0,0 +1,47 @@
+package gen
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(Idempotency(NewMemoryIdempotencyStore(), time.Minute))
+	var charges atomic.Int32
+	e.POST("/charges", func(c *Context) {
+		n := charges.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		c.SetHeader("X-Charge", "1")
+		c.JSON(http.StatusCreated, H{"charge": n})
+	})
+
+	w := performRequest(e, http.MethodPost, "/charges", nil, HeaderIdempotencyKey, "k1")
+	replay := performRequest(e, http.MethodPost, "/charges", nil, HeaderIdempotencyKey, "k1")
+	if replay.Code != http.StatusCreated || replay.Body.String() != w.Body.String() || replay.Header().Get("X-Charge") != "1" || charges.Load() != 1 {
+		t.Errorf("replay: got %d %q after %d charges", replay.Code, replay.Body.String(), charges.Load())
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = performRequest(e, http.MethodPost, "/charges", nil, HeaderIdempotencyKey, "k2").Body.String()
+		}(i)
+	}
+	wg.Wait()
+	if charges.Load() != 2 {
+		t.Errorf("concurrent duplicates charged %d times, want once", charges.Load()-1)
+	}
+	for _, body := range bodies {
+		if body != `{"charge":2}`+"\n" {
+			t.Errorf("concurrent duplicate got %q", body)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package gen
+
+import (
+	"log"
+	"runtime"
+	"time"
+)
+
+// DetectLeaks warns when a request leaves more goroutines running than it started with,
+// to catch handlers missing a `defer cancel()` and the like. The count is taken again after
+// grace to let goroutines wind down, and it's process-wide, so concurrent requests blur it:
+// a development aid, not for production.
+func DetectLeaks(grace time.Duration) HandlerFunc {
+	return func(c *Context) {
+		before := runtime.NumGoroutine()
+		c.Next()
+		deadline := time.Now().Add(grace)
+		after := runtime.NumGoroutine()
+		for after > before && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			after = runtime.NumGoroutine()
+		}
+		if after > before {
+			log.Printf("[WARNING] %s %s (%s) left %d goroutine(s) running\n", c.Method, c.Path, c.HandlerName(), after-before)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package gen
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDetectLeaks(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	stop := make(chan struct{})
+	var leaked sync.WaitGroup
+	defer leaked.Wait() // so the count of a next run isn't blurred
+	defer close(stop)
+	e := New()
+	e.Use(DetectLeaks(10 * time.Millisecond))
+	e.GET("/leak", func(c *Context) {
+		leaked.Add(1)
+		go func() {
+			defer leaked.Done()
+			<-stop
+		}()
+	})
+	e.GET("/clean", func(c *Context) {
+		done := make(chan struct{})
+		go close(done)
+		<-done
+	})
+
+	performRequest(e, http.MethodGet, "/clean", nil)
+	if logs.Len() != 0 {
+		t.Errorf("clean handler warned: %q", logs.String())
+	}
+	performRequest(e, http.MethodGet, "/leak", nil)
+	if out := logs.String(); !strings.Contains(out, "[WARNING] GET /leak") || !strings.Contains(out, "left 1 goroutine(s) running") {
+		t.Errorf("leaking handler log = %q", out)
+	}
+}
@@ -0,0 +1,48 @@
+package gen
+
+import "net/http"
+
+// RequestLimits rejects requests whose URL is longer than maxURLLength with 414,
+// or whose headers exceed maxHeaderBytes with 431. A limit <= 0 is not checked.
+// Set Engine.MaxHeaderBytes as well so the server stops reading oversized headers early.
+func RequestLimits(maxURLLength, maxHeaderBytes int) HandlerFunc {
+	return func(c *Context) {
+		uri := c.Request.RequestURI
+		if uri == "" {
+			uri = c.Request.URL.RequestURI()
+		}
+		if maxURLLength > 0 && len(uri) > maxURLLength {
+			c.AbortWithStatus(http.StatusRequestURITooLong)
+			return
+		}
+		if maxHeaderBytes > 0 && headerSize(c.Request.Header) > maxHeaderBytes {
+			c.AbortWithStatus(http.StatusRequestHeaderFieldsTooLarge)
+		}
+	}
+}
+
+// MaxBodyBytes caps the request body of the routes or groups it's used on at n bytes, so JSON
+// endpoints can stay small while uploads get more. Bodies declaring a larger Content-Length
+// are rejected with 413 upfront, others fail to read past n with an *http.MaxBytesError,
+// which the Bind methods answer with 413 too.
+func MaxBodyBytes(n int64) HandlerFunc {
+	return func(c *Context) {
+		if c.Request.ContentLength > n {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+		}
+	}
+}
+
+func headerSize(h http.Header) int {
+	n := 0
+	for k, vs := range h {
+		for _, v := range vs {
+			n += len(k) + len(v) + 4 // ": " and CRLF
+		}
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package gen
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestLimits(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(RequestLimits(64, 256))
+	e.GET("/*path", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	if w := performRequest(e, http.MethodGet, "/short", nil); w.Code != http.StatusOK {
+		t.Errorf("short URL: got %d", w.Code)
+	}
+	if w := performRequest(e, http.MethodGet, "/"+strings.Repeat("a", 64), nil); w.Code != http.StatusRequestURITooLong {
+		t.Errorf("long URL: got %d, want 414", w.Code)
+	}
+	if w := performRequest(e, http.MethodGet, "/short", nil, "X-Big", strings.Repeat("b", 256)); w.Code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("large headers: got %d, want 431", w.Code)
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	handler := func(c *Context) {
+		var v struct {
+			Data string `json:"data"`
+		}
+		if c.Bind(&v) == nil {
+			c.String(http.StatusOK, "%d", len(v.Data))
+		}
+	}
+	e.POST("/json", MaxBodyBytes(32), handler)
+	e.POST("/upload", MaxBodyBytes(1<<10), handler)
+
+	body := `{"data":"` + strings.Repeat("x", 100) + `"}`
+	if w := performRequest(e, http.MethodPost, "/upload", strings.NewReader(body), "Content-Type", MIMEJSON); w.Code != http.StatusOK || w.Body.String() != "100" {
+		t.Errorf("upload: got %d %q", w.Code, w.Body)
+	}
+	if w := performRequest(e, http.MethodPost, "/json", strings.NewReader(body), "Content-Type", MIMEJSON); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("json with Content-Length: got %d", w.Code)
+	}
+	// without a Content-Length the limit applies while reading
+	if w := performRequest(e, http.MethodPost, "/json", io.MultiReader(strings.NewReader(body)), "Content-Type", MIMEJSON); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("json without Content-Length: got %d", w.Code)
+	}
+	if w := performRequest(e, http.MethodPost, "/json", strings.NewReader(`{"data":"ok"}`), "Content-Type", MIMEJSON); w.Code != http.StatusOK {
+		t.Errorf("small json: got %d", w.Code)
+	}
+}
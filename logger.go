@@ -10,6 +10,6 @@ func Logger() HandlerFunc {
 	return func(c *Context) {
 		t := time.Now()
 		c.Next()
-		log.Printf("| %d | %13v | %15s | %-7s  %s\n", c.StatusCode, time.Since(t), c.RemoteIP(), c.Method, c.Path)
+		log.Printf("| %d | %13v | %15s | %-7s  %s\n", c.GetStatus(), time.Since(t), c.RemoteIP(), c.Method, c.Path)
 	}
 }
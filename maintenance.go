@@ -0,0 +1,26 @@
+package gen
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Maintenance answers every request with 503 and Retry-After while enabled is set,
+// except the paths in allow like a health check. Ops can flip enabled at runtime.
+func Maintenance(enabled *atomic.Bool, retryAfter time.Duration, allow ...string) HandlerFunc {
+	allowed := make(map[string]bool, len(allow))
+	for _, path := range allow {
+		allowed[path] = true
+	}
+	seconds := strconv.Itoa(int(retryAfter / time.Second))
+	return func(c *Context) {
+		if !enabled.Load() || allowed[c.Path] {
+			return
+		}
+		c.SetHeader("Retry-After", seconds)
+		c.errorPage(http.StatusServiceUnavailable, "Service Unavailable")
+		c.Abort()
+	}
+}
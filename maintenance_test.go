@@ -0,0 +1,36 @@
+package gen
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	SetMode(TestMode)
+	var enabled atomic.Bool
+	e := New()
+	e.Use(Maintenance(&enabled, 2*time.Minute, "/healthz"))
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "up")
+	}
+	e.GET("/orders", ok)
+	e.GET("/healthz", ok)
+
+	if w := performRequest(e, http.MethodGet, "/orders", nil); w.Code != http.StatusOK {
+		t.Errorf("off: got %d", w.Code)
+	}
+	enabled.Store(true)
+	w := performRequest(e, http.MethodGet, "/orders", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "120" {
+		t.Errorf("on: got %d with Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := performRequest(e, http.MethodGet, "/healthz", nil); w.Code != http.StatusOK {
+		t.Errorf("on, allow-listed: got %d", w.Code)
+	}
+	enabled.Store(false)
+	if w := performRequest(e, http.MethodGet, "/orders", nil); w.Code != http.StatusOK {
+		t.Errorf("off again: got %d", w.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+const MIMEMergePatch = "application/merge-patch+json"
+
+// ShouldBindJSONMergePatch applies the body, a JSON merge patch (RFC 7386) as sent with
+// Content-Type MIMEMergePatch, onto target, a pointer to the current state of the resource:
+// members absent from the patch are left untouched, members set to null are reset to their
+// zero value or deleted from maps, and objects are merged recursively into structs and maps.
+// Fields match like encoding/json.
+// On a type mismatch the error is returned with target possibly patched in part.
+func (c *Context) ShouldBindJSONMergePatch(target any) error {
+	patch, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+	if !json.Valid(patch) {
+		return errors.New("binding: invalid JSON merge patch")
+	}
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("binding: target must be a non-nil pointer")
+	}
+	return mergePatch(v.Elem(), patch)
+}
+
+func (c *Context) BindJSONMergePatch(target any) error {
+	return c.mustBind(c.ShouldBindJSONMergePatch(target))
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func mergePatch(v reflect.Value, patch []byte) error {
+	patch = bytes.TrimSpace(patch)
+	if len(patch) == 0 || patch[0] != '{' || reflect.PointerTo(v.Type()).Implements(jsonUnmarshalerType) {
+		return replaceValue(v, patch)
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return err
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return mergePatch(v.Elem(), patch)
+	case reflect.Interface:
+		current, ok := v.Interface().(map[string]any)
+		if !ok || v.NumMethod() > 0 {
+			return replaceValue(v, patch)
+		}
+		m := reflect.ValueOf(&current).Elem()
+		if err := mergePatch(m, patch); err != nil {
+			return err
+		}
+		v.Set(m)
+		return nil
+	case reflect.Struct:
+		for name, raw := range members {
+			field, ok := fieldByJSONName(v, name)
+			if !ok {
+				continue
+			}
+			if err := mergeMember(field, raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return replaceValue(v, patch)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for name, raw := range members {
+			key := reflect.ValueOf(name).Convert(v.Type().Key())
+			if isNull(raw) {
+				v.SetMapIndex(key, reflect.Value{})
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if current := v.MapIndex(key); current.IsValid() {
+				elem.Set(current)
+			}
+			if err := mergePatch(elem, raw); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+		return nil
+	}
+	return replaceValue(v, patch)
+}
+
+func mergeMember(field reflect.Value, raw json.RawMessage) error {
+	if isNull(raw) {
+		field.SetZero()
+		return nil
+	}
+	return mergePatch(field, raw)
+}
+
+// replaceValue sets v to the decoded value, or zero for null
+func replaceValue(v reflect.Value, data []byte) error {
+	fresh := reflect.New(v.Type())
+	if err := json.Unmarshal(data, fresh.Interface()); err != nil {
+		return err
+	}
+	v.Set(fresh.Elem())
+	return nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+// fieldByJSONName finds the field of struct v encoding/json would decode name into,
+// promoted fields of embedded structs included
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	var fold reflect.Value
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && tag == "" {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					if !embedded.CanSet() || embedded.Type().Elem().Kind() != reflect.Struct {
+						continue
+					}
+					embedded.Set(reflect.New(embedded.Type().Elem()))
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f, ok := fieldByJSONName(embedded, name); ok {
+					return f, true
+				}
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = sf.Name
+		}
+		if tag == name {
+			return v.Field(i), true
+		}
+		if !fold.IsValid() && strings.EqualFold(tag, name) {
+			fold = v.Field(i)
+		}
+	}
+	return fold, fold.IsValid()
+}
@@ -0,0 +1,50 @@
+package gen
+
+import (
+	"log"
+	"os"
+	"sync/atomic"
+)
+
+const (
+	DebugMode   = "debug"
+	ReleaseMode = "release"
+	TestMode    = "test"
+)
+
+// EnvGenMode is the environment variable read at startup for the initial mode
+const EnvGenMode = "GEN_MODE"
+
+var genMode atomic.Value
+
+func init() {
+	mode := os.Getenv(EnvGenMode)
+	if mode == "" {
+		mode = DebugMode
+	}
+	SetMode(mode)
+}
+
+// SetMode sets the package mode, only debug mode prints the verbose startup logs
+func SetMode(mode string) {
+	switch mode {
+	case DebugMode, ReleaseMode, TestMode:
+		genMode.Store(mode)
+	default:
+		panic("gen mode unknown: " + mode + " (available mode: debug release test)")
+	}
+}
+
+func Mode() string {
+	return genMode.Load().(string)
+}
+
+func IsDebugging() bool {
+	return Mode() == DebugMode
+}
+
+func debugPrint(format string, values ...any) {
+	if IsDebugging() {
+		log.Printf("[debug] "+format, values...)
+	}
+}
@@ -0,0 +1,29 @@
+package gen
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDebugPrintRoutes(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	defer SetMode(TestMode)
+
+	SetMode(DebugMode)
+	New().GET("/debug", func(c *Context) {})
+	if out := logs.String(); !strings.Contains(out, "GET    /debug") {
+		t.Errorf("debug mode log = %q, want the route", out)
+	}
+
+	logs.Reset()
+	SetMode(ReleaseMode)
+	New().GET("/release", func(c *Context) {})
+	if logs.Len() != 0 {
+		t.Errorf("release mode log = %q, want nothing", logs.String())
+	}
+}
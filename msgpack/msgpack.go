@@ -0,0 +1,45 @@
+// Package msgpack renders and binds MessagePack, a compact binary counterpart of JSON,
+// by github.com/vmihailenco/msgpack. Struct fields are named by their `msgpack` tags.
+package msgpack
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/EndlessParadox1/gen"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const MIMEMsgPack = "application/msgpack"
+
+// Binding decodes a MessagePack body, for Context.ShouldBindWith
+var Binding gen.Binding = msgpackBinding{}
+
+type msgpackBinding struct{}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+func (msgpackBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request: empty body")
+	}
+	return msgpack.NewDecoder(req.Body).Decode(obj)
+}
+
+// Render writes obj encoded as MessagePack with Content-Type MIMEMsgPack, the counterpart
+// of Context.JSON. Values msgpack can't encode, like channels, go to Context.RenderError.
+func Render(c *gen.Context, code int, obj any) {
+	data, err := msgpack.Marshal(obj)
+	if err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.Data(code, MIMEMsgPack, data)
+}
+
+// ShouldBind binds a MessagePack body into obj, only returning the error
+func ShouldBind(c *gen.Context, obj any) error {
+	return c.ShouldBindWith(obj, Binding)
+}
@@ -0,0 +1,44 @@
+package msgpack
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/EndlessParadox1/gen"
+)
+
+type item struct {
+	ID   int      `msgpack:"id"`
+	Name string   `msgpack:"name"`
+	Tags []string `msgpack:"tags"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	gen.SetMode(gen.TestMode)
+	e := gen.New()
+	want := item{ID: 7, Name: "box", Tags: []string{"a", "b"}}
+	e.GET("/", func(c *gen.Context) {
+		Render(c, http.StatusOK, want)
+	})
+	var got item
+	e.POST("/", func(c *gen.Context) {
+		if err := ShouldBind(c, &got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if ct := w.Header().Get("Content-Type"); ct != MIMEMsgPack {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(w.Body.Bytes()))
+	r.Header.Set("Content-Type", MIMEMsgPack)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package gen
+
+import (
+	"encoding/xml"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type acceptItem struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses a header like Accept or Accept-Language into its values by
+// descending quality, keeping the header order among equals
+func parseAccept(header string) []acceptItem {
+	var items []acceptItem
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		value := strings.TrimSpace(params[0])
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		items = append(items, acceptItem{value, q})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+	return items
+}
+
+// NegotiateFormat returns the offered MIME type the Accept header prefers, honoring
+// wildcards like "text/*", the first offered if there is no Accept, or "" if none fits
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	items := parseAccept(c.Request.Header.Get("Accept"))
+	if len(items) == 0 {
+		return offered[0]
+	}
+	for _, item := range items {
+		if item.q <= 0 {
+			continue
+		}
+		for _, offer := range offered {
+			if mimeMatch(item.value, offer) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+func mimeMatch(accepted, offer string) bool {
+	if accepted == "*/*" || strings.EqualFold(accepted, offer) {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(accepted, "/*"); ok {
+		return strings.HasPrefix(strings.ToLower(offer), strings.ToLower(prefix)+"/")
+	}
+	return false
+}
+
+type xmlError struct {
+	XMLName xml.Name `xml:"error"`
+	Code    int      `xml:"code"`
+	Message string   `xml:"message"`
+}
+
+// NegotiateError aborts with err rendered as JSON, XML, HTML or plain text by the Accept header.
+// HTML uses the page set by Engine.SetErrorPages for code if any.
+func (c *Context) NegotiateError(code int, err error) {
+	c.Abort()
+	message := err.Error()
+	switch c.NegotiateFormat(MIMEJSON, MIMEXML, MIMEHTML, MIMEPlain) {
+	case MIMEJSON:
+		c.JSON(code, H{"error": message})
+	case MIMEXML:
+		c.XML(code, xmlError{Code: code, Message: message})
+	case MIMEHTML:
+		if name, ok := c.engine.errorPages[code]; ok && c.engine.htmlTemplates != nil {
+			c.HTML(code, name, H{"code": code, "message": message})
+			return
+		}
+		page := "<!DOCTYPE html><html><head><title>" + strconv.Itoa(code) + " " + http.StatusText(code) +
+			"</title></head><body><h1>" + http.StatusText(code) + "</h1><p>" + template.HTMLEscapeString(message) + "</p></body></html>"
+		c.Data(code, MIMEHTML+"; charset=utf-8", []byte(page))
+	default:
+		c.String(code, "%s", message)
+	}
+}
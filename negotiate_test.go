@@ -0,0 +1,30 @@
+package gen
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateError(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/", func(c *Context) {
+		c.NegotiateError(http.StatusConflict, errors.New("name <taken>"))
+	})
+
+	for _, tt := range []struct {
+		accept, contentType, body string
+	}{
+		{MIMEJSON, MIMEJSON, `{"error":"name \u003ctaken\u003e"}` + "\n"},
+		{MIMEXML, MIMEXML, "<error><code>409</code><message>name &lt;taken&gt;</message></error>"},
+		{"text/html,*/*;q=0.8", MIMEHTML, "<h1>Conflict</h1><p>name &lt;taken&gt;</p>"},
+		{MIMEPlain, MIMEPlain, "name <taken>"},
+	} {
+		w := performRequest(e, http.MethodGet, "/", nil, "Accept", tt.accept)
+		if w.Code != http.StatusConflict || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("Accept %s: got %d %q %q", tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
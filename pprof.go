@@ -0,0 +1,44 @@
+package gen
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+// ExposePprof registers the net/http/pprof endpoints under prefix, "/debug/pprof" if empty.
+// It's opt-in, and handlers like BasicAuth can be passed to protect them.
+func (e *Engine) ExposePprof(prefix string, handlers ...HandlerFunc) {
+	if prefix == "" {
+		prefix = "/debug/pprof"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index) // also serves the named profiles
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// pprof.Index expects the standard path, so restore it after the prefix is stripped
+	e.Group(prefix, handlers...).Mount("", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.URL.Path = "/debug/pprof" + req.URL.Path
+		mux.ServeHTTP(w, req)
+	}))
+}
+
+// AdminGroup returns a group under prefix guarded by auth, with the ops endpoints registered:
+// pprof at /debug/pprof, expvar at /debug/vars, the route table at /routes and /health.
+// More can be added to the group, all behind auth.
+func (e *Engine) AdminGroup(prefix string, auth HandlerFunc) *RouterGroup {
+	if auth == nil {
+		panic("gen: AdminGroup needs an auth middleware")
+	}
+	admin := e.Group(prefix, auth)
+	// the middlewares of admin apply to every path under prefix, so these are guarded too
+	e.ExposePprof(prefix + "/debug/pprof")
+	e.ExposeRoutes(prefix + "/routes")
+	admin.GET("/debug/vars", WrapH(expvar.Handler()))
+	admin.GET("/health", func(c *Context) {
+		c.JSON(http.StatusOK, H{"status": "ok"})
+	})
+	return admin
+}
@@ -0,0 +1,55 @@
+package gen
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExposePprof(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.ExposePprof("")
+	e.ExposePprof("/internal/pprof", BasicAuth(Accounts{"ops": "secret"}))
+
+	w := performRequest(e, http.MethodGet, "/debug/pprof/", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("index: got %d %.80q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodGet, "/debug/pprof/goroutine?debug=1", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Errorf("named profile: got %d %.80q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, http.MethodGet, "/internal/pprof/", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("guarded index without credentials: got %d", w.Code)
+	}
+	w = performRequest(e, http.MethodGet, "/internal/pprof/", nil, "Authorization", "Basic b3BzOnNlY3JldA==")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("guarded index: got %d", w.Code)
+	}
+}
+
+func TestAdminGroup(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	admin := e.AdminGroup("/admin", BasicAuth(Accounts{"ops": "secret"}))
+	admin.GET("/flags", func(c *Context) {
+		c.String(http.StatusOK, "flags")
+	})
+	e.GET("/public", func(c *Context) {
+		c.String(http.StatusOK, "public")
+	})
+
+	for _, path := range []string{"/admin/health", "/admin/debug/vars", "/admin/routes", "/admin/debug/pprof/", "/admin/flags"} {
+		if w := performRequest(e, http.MethodGet, path, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s without credentials: got %d", path, w.Code)
+		}
+		w := performRequest(e, http.MethodGet, path, nil, "Authorization", "Basic b3BzOnNlY3JldA==")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s with credentials: got %d", path, w.Code)
+		}
+	}
+	if w := performRequest(e, http.MethodGet, "/public", nil); w.Code != http.StatusOK {
+		t.Errorf("outside the group: got %d", w.Code)
+	}
+}
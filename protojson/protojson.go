@@ -0,0 +1,62 @@
+// Package protojson renders and binds protobuf messages as JSON by the protojson mapping,
+// unlike Context.JSON which follows the Go struct tags: fields are named in camelCase,
+// enums by name and well-known types like Timestamp as strings, and binding also accepts
+// the original field names. The options of both directions are set by the package variables.
+package protojson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/EndlessParadox1/gen"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Binding decodes a JSON body into a proto.Message, for Context.ShouldBindWith
+var Binding gen.Binding = protojsonBinding{}
+
+// UnmarshalOptions are used by Binding, e.g. to discard unknown fields
+var UnmarshalOptions = protojson.UnmarshalOptions{}
+
+// MarshalOptions are used by Render, e.g. to use the proto field names
+var MarshalOptions = protojson.MarshalOptions{}
+
+type protojsonBinding struct{}
+
+func (protojsonBinding) Name() string {
+	return "protojson"
+}
+
+func (protojsonBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request: empty body")
+	}
+	m, ok := obj.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protojson: %T is not a proto.Message", obj)
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return UnmarshalOptions.Unmarshal(data, m)
+}
+
+// Render writes m as JSON by MarshalOptions. Marshaling fails on messages like those
+// missing required fields of proto2, passed to Context.RenderError.
+func Render(c *gen.Context, code int, m proto.Message) {
+	data, err := MarshalOptions.Marshal(m)
+	if err != nil {
+		c.RenderError(err)
+		return
+	}
+	c.Data(code, gen.MIMEJSON, data)
+}
+
+// ShouldBind binds a JSON body into m by protojson, only returning the error
+func ShouldBind(c *gen.Context, m proto.Message) error {
+	return c.ShouldBindWith(m, Binding)
+}
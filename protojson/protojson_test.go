@@ -0,0 +1,55 @@
+package protojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EndlessParadox1/gen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+func TestRender(t *testing.T) {
+	gen.SetMode(gen.TestMode)
+	e := gen.New()
+	e.GET("/", func(c *gen.Context) {
+		Render(c, http.StatusOK, &typepb.Field{Kind: typepb.Field_TYPE_STRING, JsonName: "x", OneofIndex: 2})
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if ct := w.Header().Get("Content-Type"); ct != gen.MIMEJSON {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	// protojson randomizes its whitespace, so compare the compacted output
+	var body bytes.Buffer
+	if err := json.Compact(&body, w.Body.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"kind":"TYPE_STRING","oneofIndex":2,"jsonName":"x"}`; body.String() != want {
+		t.Errorf("got %s, want %s", body.String(), want)
+	}
+}
+
+func TestShouldBind(t *testing.T) {
+	gen.SetMode(gen.TestMode)
+	e := gen.New()
+	var got typepb.Field
+	e.POST("/", func(c *gen.Context) {
+		if err := ShouldBind(c, &got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"kind":"TYPE_STRING","oneofIndex":2,"json_name":"x"}`))
+	r.Header.Set("Content-Type", gen.MIMEJSON)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+	want := &typepb.Field{Kind: typepb.Field_TYPE_STRING, JsonName: "x", OneofIndex: 2}
+	if !proto.Equal(&got, want) {
+		t.Errorf("got %v, want %v", &got, want)
+	}
+}
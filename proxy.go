@@ -0,0 +1,56 @@
+package gen
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SetTrustedProxies sets the IPs or CIDRs of the proxies whose forwarding headers,
+// like X-Forwarded-Proto, are trusted. None are trusted by default.
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("gen: invalid trusted proxy %q", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("gen: invalid trusted proxy %q: %w", proxy, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	e.trustedCIDRs = cidrs
+	return nil
+}
+
+// fromTrustedProxy reports whether the request comes straight from a trusted proxy
+func (c *Context) fromTrustedProxy() bool {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil || c.engine == nil {
+		return false
+	}
+	for _, cidr := range c.engine.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHTTPS reports whether the client uses HTTPS, trusting X-Forwarded-Proto only from
+// trusted proxies, see Engine.SetTrustedProxies
+func (c *Context) IsHTTPS() bool {
+	if c.Request.TLS != nil || c.Request.URL.Scheme == "https" {
+		return true
+	}
+	return c.fromTrustedProxy() && strings.EqualFold(c.Request.Header.Get("X-Forwarded-Proto"), "https")
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"runtime"
+	"runtime/debug"
 	"strings"
 )
 
@@ -25,11 +26,32 @@ func Recovery() HandlerFunc {
 	return func(c *Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				if bindErr, ok := err.(*BindError); ok {
+					c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": bindErr.Error()})
+					return
+				}
 				message := fmt.Sprintf("%s", err)
+				if id := c.RequestID(); id != "" {
+					message = fmt.Sprintf("[request_id %s] %s", id, message)
+				}
 				log.Printf("%s\n", traceback(message))
-				c.String(http.StatusInternalServerError, "Internal Server Error")
+				c.engine.reportPanic(c, err, debug.Stack())
+				c.errorPage(http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
 		c.Next()
 	}
 }
+
+// reportPanic calls the hook set by OnPanic, surviving its own panics
+func (e *Engine) reportPanic(c *Context, recovered any, stack []byte) {
+	if e.onPanic == nil {
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("panic in the OnPanic hook: %v\n", err)
+		}
+	}()
+	e.onPanic(c, recovered, stack)
+}
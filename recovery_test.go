@@ -0,0 +1,90 @@
+package gen
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMustBindJSONRecovery(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	e := New()
+	e.Use(Recovery())
+	e.POST("/", func(c *Context) {
+		var v struct {
+			Name string `json:"name"`
+		}
+		c.MustBindJSON(&v)
+		c.String(http.StatusOK, v.Name)
+	})
+
+	w := performRequest(e, http.MethodPost, "/", strings.NewReader(`{"name":`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"error"`) {
+		t.Errorf("malformed: got %d %q", w.Code, w.Body.String())
+	}
+	if logs.Len() != 0 {
+		t.Errorf("a bind error was logged as a panic: %q", logs.String())
+	}
+	w = performRequest(e, http.MethodPost, "/", strings.NewReader(`{"name":"ann"}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusOK || w.Body.String() != "ann" {
+		t.Errorf("valid: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestOnPanic(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	e := New()
+	e.Use(Recovery())
+	var recovered any
+	var stack []byte
+	e.OnPanic(func(c *Context, r any, s []byte) {
+		recovered, stack = r, s
+		panic("tracker down")
+	})
+	e.GET("/", func(c *Context) {
+		panic("boom")
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if recovered != "boom" || !bytes.Contains(stack, []byte("TestOnPanic")) {
+		t.Errorf("hook got %v with stack %q", recovered, stack)
+	}
+	if !strings.Contains(logs.String(), "panic in the OnPanic hook: tracker down") {
+		t.Errorf("log = %q", logs.String())
+	}
+}
+
+func TestRecoveryLogsRequestID(t *testing.T) {
+	SetMode(TestMode)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	e := New()
+	e.Use(Recovery(), RequestID())
+	e.GET("/", func(c *Context) {
+		panic("boom")
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil, HeaderXRequestID, "req-7f3a")
+	if w.Code != http.StatusInternalServerError || w.Header().Get(HeaderXRequestID) != "req-7f3a" {
+		t.Errorf("got %d with %s %q", w.Code, HeaderXRequestID, w.Header().Get(HeaderXRequestID))
+	}
+	if !strings.Contains(logs.String(), "[request_id req-7f3a] boom") {
+		t.Errorf("log %q", logs.String())
+	}
+}
@@ -0,0 +1,37 @@
+package gen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const HeaderXRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID reuses the X-Request-ID of the request or generates one, echoes it in the response
+// and stores it in the request's context.Context, so libraries down the line can log it.
+func RequestID() HandlerFunc {
+	return func(c *Context) {
+		id := c.Request.Header.Get(HeaderXRequestID)
+		if id == "" {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.SetHeader(HeaderXRequestID, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+	}
+}
+
+// RequestID returns the ID set by the RequestID middleware, or "" without it
+func (c *Context) RequestID() string {
+	return RequestIDFromContext(c.Request.Context())
+}
+
+// RequestIDFromContext returns the ID set by the RequestID middleware from a context.Context
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,28 @@
+package gen
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRequestIDInContext(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(RequestID())
+	var fromCtx string
+	e.GET("/", func(c *Context) {
+		lookup := func(ctx context.Context) { fromCtx = RequestIDFromContext(ctx) }
+		lookup(c.Request.Context())
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil, HeaderXRequestID, "abc-123")
+	if fromCtx != "abc-123" || w.Header().Get(HeaderXRequestID) != "abc-123" {
+		t.Errorf("context ID %q, header %q, want the incoming one", fromCtx, w.Header().Get(HeaderXRequestID))
+	}
+
+	w = performRequest(e, http.MethodGet, "/", nil)
+	if len(fromCtx) != 32 || w.Header().Get(HeaderXRequestID) != fromCtx {
+		t.Errorf("context ID %q, header %q, want the same generated one", fromCtx, w.Header().Get(HeaderXRequestID))
+	}
+}
@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"net/http"
+	"strings"
+)
+
+type RequireHeadersConfig struct {
+	Headers []string
+	// Status is the status of the rejection, 400 by default
+	Status int
+	// Message is the error of the JSON body, "missing required headers" by default
+	Message string
+}
+
+// RequireHeaders aborts with 400 listing the missing headers among names
+func RequireHeaders(names ...string) HandlerFunc {
+	return RequireHeadersWithConfig(RequireHeadersConfig{Headers: names})
+}
+
+func RequireHeadersWithConfig(config RequireHeadersConfig) HandlerFunc {
+	if config.Status == 0 {
+		config.Status = http.StatusBadRequest
+	}
+	if config.Message == "" {
+		config.Message = "missing required headers"
+	}
+	return func(c *Context) {
+		var missing []string
+		for _, name := range config.Headers {
+			if c.Request.Header.Get(name) == "" {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			c.AbortWithStatusJSON(config.Status, H{"error": config.Message, "missing": missing})
+		}
+	}
+}
+
+// RequireContentType aborts with 415 if the request has a body whose Content-Type, parameters
+// like charset aside, is none of types. Requests without a body pass.
+func RequireContentType(types ...string) HandlerFunc {
+	return func(c *Context) {
+		if c.Request.ContentLength == 0 && len(c.Request.TransferEncoding) == 0 {
+			return
+		}
+		ct := c.ContentType()
+		for _, t := range types {
+			if strings.EqualFold(ct, t) {
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, H{"error": "unsupported content type", "supported": types})
+	}
+}
+
+// APIVersionKey is the key of the version resolved by RequireAPIVersion
+const APIVersionKey = "api_version"
+
+// RequireAPIVersion reads the API version from the Accept-Version or X-API-Version header,
+// defaulting to the first of supported when both are absent, and aborts with 400 listing
+// the supported versions if it's none of them. The version is stored under APIVersionKey.
+func RequireAPIVersion(supported ...string) HandlerFunc {
+	return func(c *Context) {
+		version := c.Request.Header.Get("Accept-Version")
+		if version == "" {
+			version = c.Request.Header.Get("X-API-Version")
+		}
+		if version == "" && len(supported) > 0 {
+			version = supported[0]
+		}
+		for _, v := range supported {
+			if v == version {
+				c.Set(APIVersionKey, version)
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": "unsupported API version", "supported": supported})
+	}
+}
+
+// APIVersion returns the version resolved by RequireAPIVersion, or "" without it
+func (c *Context) APIVersion() string {
+	version, _ := GetTyped[string](c, APIVersionKey)
+	return version
+}
@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequireHeaders(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/", RequireHeaders("X-Tenant", "X-Client"), func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := performRequest(e, http.MethodGet, "/", nil, "X-Tenant", "t1", "X-Client", "web")
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("all present: got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodGet, "/", nil, "X-Tenant", "t1")
+	want := `{"error":"missing required headers","missing":["X-Client"]}` + "\n"
+	if w.Code != http.StatusBadRequest || w.Body.String() != want {
+		t.Errorf("one missing: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRequireContentType(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(RequireContentType(MIMEJSON))
+	e.POST("/", func(c *Context) {
+		c.String(http.StatusOK, "accepted")
+	})
+
+	if w := performRequest(e, http.MethodPost, "/", strings.NewReader(`{}`), "Content-Type", "application/json; charset=utf-8"); w.Code != http.StatusOK {
+		t.Errorf("allowed: got %d", w.Code)
+	}
+	w := performRequest(e, http.MethodPost, "/", strings.NewReader(`<a/>`), "Content-Type", MIMEXML)
+	if w.Code != http.StatusUnsupportedMediaType || !strings.Contains(w.Body.String(), `"supported":["application/json"]`) {
+		t.Errorf("XML: got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, http.MethodPost, "/", strings.NewReader(`{}`)); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("missing header with a body: got %d", w.Code)
+	}
+	if w := performRequest(e, http.MethodPost, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("missing header without a body: got %d", w.Code)
+	}
+}
+
+func TestRequireAPIVersion(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/", RequireAPIVersion("2024-01", "2023-06"), func(c *Context) {
+		c.String(http.StatusOK, "%s", c.APIVersion())
+	})
+
+	for _, tt := range []struct {
+		headers []string
+		code    int
+		body    string
+	}{
+		{[]string{"Accept-Version", "2023-06"}, http.StatusOK, "2023-06"},
+		{[]string{"X-API-Version", "2023-06"}, http.StatusOK, "2023-06"},
+		{nil, http.StatusOK, "2024-01"},
+		{[]string{"Accept-Version", "2022-01"}, http.StatusBadRequest,
+			`{"error":"unsupported API version","supported":["2024-01","2023-06"]}` + "\n"},
+	} {
+		w := performRequest(e, http.MethodGet, "/", nil, tt.headers...)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%v: got %d %q", tt.headers, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,15 +1,150 @@
 package gen
 
-import "net/http"
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+var ErrHijackNotSupported = errors.New("gen: the response writer does not support hijacking")
+
+// ResponseWriter is the http.ResponseWriter handlers write through
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+
+	// Status returns the status code written, 200 if nothing was written yet
+	Status() int
+	// Written reports whether the status line has been written
+	Written() bool
+	// Size returns the number of body bytes written
+	Size() int
+}
 
 type responseWriter struct {
 	http.ResponseWriter
+	status  int
+	written bool
+	size    int
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w}
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader is idempotent, the status written first wins. Statuses without a body,
+// like 204 and 304, are sent without Content-Type and Content-Length, whatever rendered them.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.written {
+		return
+	}
+	w.status = code
+	w.written = true
+	if !bodyAllowed(code) {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write discards the data of responses whose status forbids a body, like 204 and 304,
+// so a handler rendering one anyway doesn't fail
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !bodyAllowed(w.status) {
+		return len(data), nil
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
 }
 
+func (w *responseWriter) Written() bool {
+	return w.written
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Flush does nothing if the underlying writer can't flush
 func (w *responseWriter) Flush() {
-	w.ResponseWriter.(http.Flusher).Flush()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
+
+// copyWriter is the writer of a copied Context, which must not write the response
+type copyWriter struct {
+	status int
+	size   int
+}
+
+const errCopyWrite = "gen: the response can't be written through a copied Context"
+
+func (copyWriter) Header() http.Header {
+	panic(errCopyWrite)
+}
+
+func (copyWriter) Write([]byte) (int, error) {
+	panic(errCopyWrite)
+}
+
+func (copyWriter) WriteHeader(int) {
+	panic(errCopyWrite)
+}
+
+func (copyWriter) Flush() {
+	panic(errCopyWrite)
+}
+
+func (copyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	panic(errCopyWrite)
+}
+
+func (copyWriter) Push(string, *http.PushOptions) error {
+	panic(errCopyWrite)
+}
+
+func (w copyWriter) Status() int {
+	return w.status
+}
+
+func (copyWriter) Written() bool {
+	return true
+}
+
+func (w copyWriter) Size() int {
+	return w.size
+}
+
+// bodyAllowed reports whether responses with status may have a body, see RFC 9110
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
 }
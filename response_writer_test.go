@@ -0,0 +1,66 @@
+package gen
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoSuperfluousWriteHeader(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	e.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, H{"ok": true})
+		c.String(http.StatusAccepted, "more")
+	})
+	var logs bytes.Buffer
+	srv := httptest.NewUnstartedServer(e)
+	srv.Config.ErrorLog = log.New(&logs, "", 0)
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || string(body) != `{"ok":true}`+"\nmore" {
+		t.Errorf("got %d %q", resp.StatusCode, body)
+	}
+	if logs.Len() > 0 {
+		t.Errorf("server log = %q", logs.String())
+	}
+}
+
+func TestNoBodyStatuses(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.DELETE("/items/1", func(c *Context) {
+		c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/items/1", func(c *Context) {
+		// a handler rendering anyway doesn't put a body on a 304
+		c.JSON(http.StatusNotModified, H{"id": 1})
+	})
+	e.PUT("/items/1", func(c *Context) {
+		c.JSON(http.StatusNoContent, H{"id": 1})
+	})
+
+	for _, method := range []string{http.MethodDelete, http.MethodGet, http.MethodPut} {
+		w := performRequest(e, method, "/items/1", nil)
+		if w.Body.Len() != 0 || w.Header().Get("Content-Length") != "" || w.Header().Get("Content-Type") != "" {
+			t.Errorf("%s: got %d with body %q, Content-Length %q, Content-Type %q", method, w.Code, w.Body,
+				w.Header().Get("Content-Length"), w.Header().Get("Content-Type"))
+		}
+	}
+	if w := performRequest(e, http.MethodDelete, "/items/1", nil); w.Code != http.StatusNoContent {
+		t.Errorf("NoContent: got %d", w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Route is a handle to the routes registered by one call like GET or Any,
+// for attaching metadata that Engine.Routes reports, e.g. to build an OpenAPI skeleton.
+type Route struct {
+	engine *Engine
+	infos  []*RouteInfo
+}
+
+func newRoute(engine *Engine, infos ...*RouteInfo) *Route {
+	return &Route{engine, infos}
+}
+
+// Name names the route for building its URL by Engine.URL, it panics if the name is taken
+func (r *Route) Name(name string) *Route {
+	if _, ok := r.engine.namedRoutes[name]; ok {
+		panic("route name \"" + name + "\" is already used")
+	}
+	if r.engine.namedRoutes == nil {
+		r.engine.namedRoutes = make(map[string]string)
+	}
+	for _, info := range r.infos {
+		info.Name = name
+		r.engine.namedRoutes[name] = info.Path
+	}
+	return r
+}
+
+// WithSummary sets a one-line description of the route, reported by Engine.Routes
+func (r *Route) WithSummary(summary string) *Route {
+	for _, info := range r.infos {
+		info.Summary = summary
+	}
+	return r
+}
+
+// WithTags adds tags to the route for grouping it, also readable by middlewares through Context.RouteTag
+func (r *Route) WithTags(tags ...string) *Route {
+	for _, info := range r.infos {
+		info.Tags = append(info.Tags, tags...)
+	}
+	return r
+}
+
+var ErrRouteNotFound = errors.New("route not found")
+
+// URL builds the path of the route named name, substituting its :param and *catchall
+// segments by params, which are escaped
+func (e *Engine) URL(name string, params map[string]string) (string, error) {
+	pattern, ok := e.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	return buildPath(pattern, func(param string) (string, bool) {
+		value, ok := params[param]
+		return value, ok
+	})
+}
+
+// Reverse builds the path of the route named name like URL, taking the values of its
+// :param and *catchall segments in order. It's the "url" function of the HTML templates.
+func (e *Engine) Reverse(name string, params ...string) (string, error) {
+	pattern, ok := e.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	i := 0
+	path, err := buildPath(pattern, func(string) (string, bool) {
+		if i >= len(params) {
+			return "", false
+		}
+		i++
+		return params[i-1], true
+	})
+	if err == nil && i < len(params) {
+		return "", fmt.Errorf("too many route params for %s: %d given", pattern, len(params))
+	}
+	return path, err
+}
+
+// buildPath fills the :param and *catchall segments of pattern by lookup
+func buildPath(pattern string, lookup func(param string) (string, bool)) (string, error) {
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		value, ok := lookup(seg[1:])
+		if !ok {
+			return "", fmt.Errorf("missing route param %q of %s", seg[1:], pattern)
+		}
+		if seg[0] == ':' {
+			if value == "" {
+				return "", fmt.Errorf("empty route param %q of %s", seg[1:], pattern)
+			}
+			segments[i] = url.PathEscape(value)
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for j, part := range parts {
+			parts[j] = url.PathEscape(part)
+		}
+		segments[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(segments, "/"), nil
+}
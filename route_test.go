@@ -0,0 +1,109 @@
+package gen
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRouteMetadata(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/users", func(c *Context) {}).WithSummary("List users").WithTags("users")
+	e.Any("/health", func(c *Context) {}).WithTags("ops", "public")
+	e.POST("/users", func(c *Context) {})
+
+	for _, r := range e.Routes() {
+		switch {
+		case r.Path == "/users" && r.Method == http.MethodGet:
+			if r.Summary != "List users" || !reflect.DeepEqual(r.Tags, []string{"users"}) {
+				t.Errorf("GET /users = %q %v", r.Summary, r.Tags)
+			}
+		case r.Path == "/health":
+			if r.Summary != "" || !reflect.DeepEqual(r.Tags, []string{"ops", "public"}) {
+				t.Errorf("%s /health = %q %v", r.Method, r.Summary, r.Tags)
+			}
+		default:
+			if r.Summary != "" || r.Tags != nil {
+				t.Errorf("%s %s got metadata %q %v", r.Method, r.Path, r.Summary, r.Tags)
+			}
+		}
+	}
+}
+
+func TestURLAndRedirectToRoute(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/users/:id/posts/:post", func(c *Context) {}).Name("post")
+	e.GET("/go/:id", func(c *Context) {
+		if err := c.RedirectToRoute(http.StatusFound, "post", map[string]string{"id": c.Param("id"), "post": "first"}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if u, err := e.URL("post", map[string]string{"id": "7", "post": "hello"}); u != "/users/7/posts/hello" || err != nil {
+		t.Errorf("URL = %q, %v", u, err)
+	}
+	if _, err := e.URL("nope", nil); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("URL of an unknown name = %v, want ErrRouteNotFound", err)
+	}
+	w := performRequest(e, http.MethodGet, "/go/9", nil)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/users/9/posts/first" {
+		t.Errorf("redirect: got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestReverse(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/users/:id/files/*path", func(c *Context) {}).Name("file")
+
+	if u, err := e.Reverse("file", "a b", "/docs/q&a.txt"); u != "/users/a%20b/files/docs/q&a.txt" || err != nil {
+		t.Errorf("Reverse = %q, %v", u, err)
+	}
+	if u, err := e.Reverse("file", "x/y", "z"); u != "/users/x%2Fy/files/z" || err != nil {
+		t.Errorf("Reverse with a slash in a param = %q, %v", u, err)
+	}
+	if _, err := e.Reverse("file", "7"); err == nil {
+		t.Error("Reverse with a missing param succeeded")
+	}
+	if _, err := e.Reverse("file", "7", "a", "b"); err == nil {
+		t.Error("Reverse with too many params succeeded")
+	}
+}
+
+func TestRouteTagInMiddleware(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var summary string
+	e.Use(func(c *Context) {
+		if route := c.Route(); route != nil {
+			summary = route.Summary
+		}
+		if !c.RouteTag("public") && c.Request.Header.Get("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	ok := func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}
+	e.GET("/health", ok).WithTags("ops", "public")
+	e.GET("/orders", ok).WithSummary("List orders").WithTags("orders")
+
+	if w := performRequest(e, http.MethodGet, "/health", nil); w.Code != http.StatusOK {
+		t.Errorf("public route: got %d", w.Code)
+	}
+	if w := performRequest(e, http.MethodGet, "/orders", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("private route: got %d", w.Code)
+	}
+	if summary != "List orders" {
+		t.Errorf("middleware saw summary %q", summary)
+	}
+	if w := performRequest(e, http.MethodGet, "/orders", nil, "Authorization", "Bearer t"); w.Code != http.StatusOK {
+		t.Errorf("private route with credentials: got %d", w.Code)
+	}
+	if w := performRequest(e, http.MethodGet, "/missing", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("no route: got %d", w.Code)
+	}
+}
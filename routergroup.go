@@ -1,7 +1,6 @@
 package gen
 
 import (
-	"log"
 	"net/http"
 	"path"
 	"reflect"
@@ -30,71 +29,118 @@ func (g *RouterGroup) Group(prefix string, handlers ...HandlerFunc) *RouterGroup
 	return newGroup
 }
 
+// Version returns a group for an API version, prefixed like /v1 for "v1", so its routes are
+// registered without repeating the prefix. They match with the prefix in place; handing the
+// rest of the path to a wrapped mux is what StripPrefix is for.
+func (g *RouterGroup) Version(version string, handlers ...HandlerFunc) *RouterGroup {
+	return g.Group("/"+version, handlers...)
+}
+
 func (g *RouterGroup) Use(middlewares ...HandlerFunc) {
 	g.middlewares = append(g.middlewares, middlewares...)
 }
 
-func (g *RouterGroup) addRoute(method, comp string, handlers ...HandlerFunc) {
-	path_ := g.prefix + comp
+// UseForMethods is like Use, but the middlewares only run for requests of the methods,
+// e.g. CSRF checks on POST, PUT and DELETE
+func (g *RouterGroup) UseForMethods(methods []string, middlewares ...HandlerFunc) {
+	set := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		set[strings.ToUpper(method)] = true
+	}
+	for _, mw := range middlewares {
+		g.Use(func(c *Context) {
+			if set[c.Method] {
+				mw(c)
+			}
+		})
+	}
+}
+
+func (g *RouterGroup) addRoute(method, comp string, handlers ...HandlerFunc) *RouteInfo {
+	path_, match := parseConstraints(g.prefix + comp)
 	len_ := len(handlers)
 	f := handlers[len_-1]
 	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
-	log.Printf("%-6s %-25s --> %s\n", method, path_, name)
-	g.engine.router.Handle(method, path_, func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
-		c := newContext(w, req, params)
-		c.engine = g.engine
+	if IsDebugging() {
+		n := len(handlers)
 		for _, group := range g.engine.groups {
-			if strings.HasPrefix(req.URL.Path, group.prefix) {
-				c.handlers = append(c.handlers, group.middlewares...)
+			if strings.HasPrefix(path_, group.prefix) {
+				n += len(group.middlewares)
 			}
 		}
-		c.handlers = append(c.handlers, handlers...)
-		c.Next()
+		debugPrint("%-6s %-25s --> %s (%d handlers)\n", method, path_, name, n)
+	}
+	info := &RouteInfo{
+		Method:      method,
+		Path:        path_,
+		Handler:     name,
+		HandlerFunc: f,
+	}
+	g.engine.routes = append(g.engine.routes, info)
+	g.engine.router.Handle(method, path_, func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		if match != nil && !match(params) {
+			// not this route after all, answered by NoRoute without running any middleware
+			g.engine.serve(w, req, nil, nil, g.engine.noRouteHandlers())
+			return
+		}
+		g.engine.handle(w, req, params, info, handlers)
 	})
+	return info
+}
+
+func (g *RouterGroup) GET(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodGet, path, handlers...))
 }
 
-func (g *RouterGroup) GET(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodGet, path, handlers...)
+func (g *RouterGroup) POST(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodPost, path, handlers...))
 }
 
-func (g *RouterGroup) POST(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodPost, path, handlers...)
+func (g *RouterGroup) PUT(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodPut, path, handlers...))
 }
 
-func (g *RouterGroup) DELETE(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodDelete, path, handlers...)
+func (g *RouterGroup) DELETE(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodDelete, path, handlers...))
 }
 
-func (g *RouterGroup) HEAD(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodHead, path, handlers...)
+func (g *RouterGroup) HEAD(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodHead, path, handlers...))
 }
 
-func (g *RouterGroup) PATCH(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodPatch, path, handlers...)
+func (g *RouterGroup) PATCH(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodPatch, path, handlers...))
 }
 
-func (g *RouterGroup) CONNECT(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodConnect, path, handlers...)
+func (g *RouterGroup) CONNECT(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodConnect, path, handlers...))
 }
 
-func (g *RouterGroup) OPTIONS(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodOptions, path, handlers...)
+func (g *RouterGroup) OPTIONS(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodOptions, path, handlers...))
 }
 
-func (g *RouterGroup) TRACE(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodTrace, path, handlers...)
+func (g *RouterGroup) TRACE(path string, handlers ...HandlerFunc) *Route {
+	return newRoute(g.engine, g.addRoute(http.MethodTrace, path, handlers...))
+}
+
+func (g *RouterGroup) Any(path string, handlers ...HandlerFunc) *Route {
+	methods := []string{
+		http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodPut, http.MethodHead,
+		http.MethodPatch, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	}
+	infos := make([]*RouteInfo, len(methods))
+	for i, method := range methods {
+		infos[i] = g.addRoute(method, path, handlers...)
+	}
+	return newRoute(g.engine, infos...)
 }
 
-func (g *RouterGroup) Any(path string, handlers ...HandlerFunc) {
-	g.addRoute(http.MethodGet, path, handlers...)
-	g.addRoute(http.MethodPost, path, handlers...)
-	g.addRoute(http.MethodDelete, path, handlers...)
-	g.addRoute(http.MethodPut, path, handlers...)
-	g.addRoute(http.MethodHead, path, handlers...)
-	g.addRoute(http.MethodPatch, path, handlers...)
-	g.addRoute(http.MethodConnect, path, handlers...)
-	g.addRoute(http.MethodOptions, path, handlers...)
-	g.addRoute(http.MethodTrace, path, handlers...)
+// Mount delegates every request under prefix to handler, like pprof or a legacy http.ServeMux,
+// with the group prefix and prefix stripped from the URL path.
+func (g *RouterGroup) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	g.Any(prefix+"/*filePath", WrapH(http.StripPrefix(g.prefix+prefix, handler)))
 }
 
 func (g *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
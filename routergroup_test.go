@@ -0,0 +1,93 @@
+package gen
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	SetMode(TestMode)
+	var seen string
+	legacy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Path
+		w.Write([]byte("legacy"))
+	})
+	e := New()
+	e.Group("/api").Mount("/legacy/", legacy)
+
+	w := performRequest(e, http.MethodGet, "/api/legacy/users/1", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "legacy" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	if seen != "/users/1" {
+		t.Errorf("mounted handler saw %q, want /users/1", seen)
+	}
+}
+
+func TestUseForMethods(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	api := e.Group("/api")
+	api.UseForMethods([]string{"post", http.MethodDelete}, func(c *Context) {
+		if c.Request.Header.Get("X-CSRF-Token") == "" {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	})
+	handler := func(c *Context) {
+		c.String(http.StatusOK, c.Method)
+	}
+	api.GET("/items", handler)
+	api.POST("/items", handler)
+	api.DELETE("/items", handler)
+
+	for _, tt := range []struct {
+		method string
+		code   int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodPost, http.StatusForbidden},
+		{http.MethodDelete, http.StatusForbidden},
+	} {
+		if w := performRequest(e, tt.method, "/api/items", nil); w.Code != tt.code {
+			t.Errorf("%s: got %d, want %d", tt.method, w.Code, tt.code)
+		}
+	}
+	if w := performRequest(e, http.MethodPost, "/api/items", nil, "X-CSRF-Token", "t"); w.Code != http.StatusOK {
+		t.Errorf("POST with token: got %d", w.Code)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var versions []string
+	api := e.Group("/api")
+	v1 := api.Version("v1", func(c *Context) {
+		versions = append(versions, "v1")
+	})
+	v1.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.FullPath(), c.Param("id"))
+	})
+	v2 := api.Version("v2", func(c *Context) {
+		versions = append(versions, "v2")
+	})
+	v2.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "v2 user %s", c.Param("id"))
+	})
+
+	w := performRequest(e, http.MethodGet, "/api/v1/users/7", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "/api/v1/users/:id 7" {
+		t.Errorf("v1: got %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodGet, "/api/v2/users/7", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "v2 user 7" {
+		t.Errorf("v2: got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, http.MethodGet, "/users/7", nil); w.Code != http.StatusNotFound {
+		t.Errorf("without the prefix: got %d", w.Code)
+	}
+	if strings.Join(versions, ",") != "v1,v2" {
+		t.Errorf("version middlewares ran as %v", versions)
+	}
+}
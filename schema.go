@@ -0,0 +1,76 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SchemaValidator validates a JSON document, like an adapter of a JSON Schema library,
+// returning SchemaErrors for the failures
+type SchemaValidator interface {
+	ValidateJSON(data []byte) error
+}
+
+// SchemaValidatorFunc is a function used as a SchemaValidator
+type SchemaValidatorFunc func(data []byte) error
+
+func (f SchemaValidatorFunc) ValidateJSON(data []byte) error {
+	return f(data)
+}
+
+// SchemaError is a failure of the value at Path, a JSON Pointer like "/items/0/price"
+type SchemaError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+type SchemaErrors []SchemaError
+
+func (errs SchemaErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// maxSchemaBodySize caps the body read whole for validation, like GetRawData's default
+const maxSchemaBodySize = 10 << 20
+
+// JSONSchema returns a binding that validates the JSON body by validator before decoding it,
+// for contract-first APIs: c.ShouldBindWith(&obj, gen.JSONSchema(validator)). Bodies over
+// 10 MiB fail with an *http.MaxBytesError, which the Bind methods answer with 413; use
+// MaxBodyBytes for a lower limit.
+func JSONSchema(validator SchemaValidator) Binding {
+	return schemaBinding{validator}
+}
+
+type schemaBinding struct {
+	validator SchemaValidator
+}
+
+func (schemaBinding) Name() string {
+	return "json"
+}
+
+func (b schemaBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errNilBody
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, maxSchemaBodySize))
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	if err := b.validator.ValidateJSON(data); err != nil {
+		return err
+	}
+	return BindingJSON.Bind(req, obj)
+}
@@ -0,0 +1,301 @@
+package gen
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const sessionKey = "gen/session"
+
+var ErrInvalidSession = errors.New("session: invalid or tampered cookie")
+
+// Store loads, saves and destroys session values by the session cookie name.
+// Values are encoded as JSON, so numbers come back as float64.
+type Store interface {
+	Load(c *Context, name string) (map[string]any, error)
+	Save(c *Context, name string, values map[string]any) error
+	Destroy(c *Context, name string) error
+}
+
+type SessionOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+}
+
+func defaultSessionOptions() SessionOptions {
+	return SessionOptions{Path: "/", MaxAge: 86400 * 7, HttpOnly: true}
+}
+
+func (o SessionOptions) cookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		MaxAge:   o.MaxAge,
+		Secure:   o.Secure,
+		HttpOnly: o.HttpOnly,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Session is the session of a request, see Context.Session
+type Session struct {
+	name   string
+	store  Store
+	c      *Context
+	values map[string]any
+}
+
+func (s *Session) Get(key string) any {
+	return s.values[key]
+}
+
+func (s *Session) Set(key string, value any) {
+	s.values[key] = value
+}
+
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+}
+
+func (s *Session) Clear() {
+	s.values = make(map[string]any)
+}
+
+// Save persists the session, it must be called before the response is written
+func (s *Session) Save() error {
+	return s.store.Save(s.c, s.name, s.values)
+}
+
+// Destroy clears the session and removes it from the store and the client, like on logout.
+// Like Save, it must be called before the response is written.
+func (s *Session) Destroy() error {
+	s.values = make(map[string]any)
+	return s.store.Destroy(s.c, s.name)
+}
+
+// Sessions loads the session named name from store for Context.Session.
+// A missing or invalid session starts empty.
+func Sessions(name string, store Store) HandlerFunc {
+	return func(c *Context) {
+		values, err := store.Load(c, name)
+		if err != nil || values == nil {
+			values = make(map[string]any)
+		}
+		c.Set(sessionKey, &Session{name: name, store: store, c: c, values: values})
+	}
+}
+
+// Session returns the session loaded by the Sessions middleware
+func (c *Context) Session() *Session {
+	return c.MustGet(sessionKey).(*Session)
+}
+
+// CookieStore keeps the session values in the cookie itself, encrypted and authenticated
+// with AES-GCM so clients can neither read nor tamper with them.
+type CookieStore struct {
+	Options SessionOptions
+	aead    cipher.AEAD
+}
+
+func NewCookieStore(secret []byte) *CookieStore {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &CookieStore{Options: defaultSessionOptions(), aead: aead}
+}
+
+func (s *CookieStore) Load(c *Context, name string) (map[string]any, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return nil, ErrInvalidSession
+	}
+	nonce, sealed := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plain, err := s.aead.Open(nil, nonce, sealed, []byte(name))
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+	var values map[string]any
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, ErrInvalidSession
+	}
+	return values, nil
+}
+
+func (s *CookieStore) Save(c *Context, name string, values map[string]any) error {
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := s.aead.Seal(nonce, nonce, plain, []byte(name))
+	http.SetCookie(c.Writer, s.Options.cookie(name, base64.RawURLEncoding.EncodeToString(data)))
+	return nil
+}
+
+func (s *CookieStore) Destroy(c *Context, name string) error {
+	cookie := s.Options.cookie(name, "")
+	cookie.MaxAge = -1
+	http.SetCookie(c.Writer, cookie)
+	return nil
+}
+
+// MemoryStore keeps the session values in process memory, the cookie only carries a random ID.
+// Sessions are lost on restart and not shared between instances. Each one expires Options.MaxAge
+// seconds after its last save, or a day if MaxAge isn't positive, and expired ones are swept
+// by Load and Save.
+type MemoryStore struct {
+	Options   SessionOptions
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	lastSweep time.Time
+}
+
+type memorySession struct {
+	data    []byte
+	expires time.Time
+}
+
+// memorySweepInterval bounds how often Load and Save scan all sessions for expired ones
+const memorySweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Options: defaultSessionOptions(), sessions: make(map[string]memorySession)}
+}
+
+func (s *MemoryStore) ttl() time.Duration {
+	if s.Options.MaxAge > 0 {
+		return time.Duration(s.Options.MaxAge) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// get returns the unexpired session id, s.mu must be held
+func (s *MemoryStore) get(id string, now time.Time) (memorySession, bool) {
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, sess := range s.sessions {
+			if !now.Before(sess.expires) {
+				delete(s.sessions, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	sess, ok := s.sessions[id]
+	if ok && !now.Before(sess.expires) {
+		delete(s.sessions, id)
+		return memorySession{}, false
+	}
+	return sess, ok
+}
+
+func (s *MemoryStore) Load(c *Context, name string) (map[string]any, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	sess, ok := s.get(cookie.Value, time.Now())
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+	var values map[string]any
+	err = json.Unmarshal(sess.data, &values) // a copy, so handlers don't share the map
+	return values, err
+}
+
+func (s *MemoryStore) Save(c *Context, name string, values map[string]any) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	id := ""
+	if cookie, err := c.Request.Cookie(name); err == nil {
+		s.mu.Lock()
+		if _, ok := s.get(cookie.Value, now); ok {
+			id = cookie.Value
+		}
+		s.mu.Unlock()
+	}
+	if id == "" {
+		b := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return err
+		}
+		id = hex.EncodeToString(b)
+	}
+	s.mu.Lock()
+	s.sessions[id] = memorySession{data: data, expires: now.Add(s.ttl())}
+	s.mu.Unlock()
+	http.SetCookie(c.Writer, s.Options.cookie(name, id))
+	return nil
+}
+
+func (s *MemoryStore) Destroy(c *Context, name string) error {
+	if cookie, err := c.Request.Cookie(name); err == nil {
+		s.mu.Lock()
+		delete(s.sessions, cookie.Value)
+		s.mu.Unlock()
+	}
+	cookie := s.Options.cookie(name, "")
+	cookie.MaxAge = -1
+	http.SetCookie(c.Writer, cookie)
+	return nil
+}
+
+const flashKey = "_flash"
+
+// AddFlash adds a one-time message to the session and saves it, so it survives a redirect.
+// Like Session.Save, it must be called before the response is written.
+func (c *Context) AddFlash(msg string) error {
+	s := c.Session()
+	flashes, _ := s.Get(flashKey).([]any)
+	s.Set(flashKey, append(flashes, msg))
+	return s.Save()
+}
+
+// Flashes returns the flash messages and clears them from the session
+func (c *Context) Flashes() []string {
+	s := c.Session()
+	flashes, _ := s.Get(flashKey).([]any)
+	if len(flashes) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(flashes))
+	for _, f := range flashes {
+		if msg, ok := f.(string); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	s.Delete(flashKey)
+	s.Save()
+	return msgs
+}
@@ -0,0 +1,145 @@
+package gen
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiryAndDestroy(t *testing.T) {
+	SetMode(TestMode)
+	store := NewMemoryStore()
+	e := New()
+	e.Use(Sessions("sid", store))
+	e.GET("/login", func(c *Context) {
+		c.Session().Set("user", "ann")
+		c.Session().Save()
+	})
+	e.GET("/me", func(c *Context) {
+		user, _ := c.Session().Get("user").(string)
+		c.String(http.StatusOK, user)
+	})
+	e.GET("/logout", func(c *Context) {
+		c.Session().Destroy()
+	})
+	get := func(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		return w
+	}
+
+	cookie := get("/login", nil).Result().Cookies()[0]
+	if body := get("/me", cookie).Body.String(); body != "ann" {
+		t.Fatalf("me = %q, want ann", body)
+	}
+
+	// an expired session is gone, and the sweep drops the other expired ones
+	store.mu.Lock()
+	store.sessions["stale"] = memorySession{expires: time.Now().Add(-time.Second)}
+	sess := store.sessions[cookie.Value]
+	sess.expires = time.Now().Add(-time.Second)
+	store.sessions[cookie.Value] = sess
+	store.lastSweep = time.Time{}
+	store.mu.Unlock()
+	if body := get("/me", cookie).Body.String(); body != "" {
+		t.Errorf("me after expiry = %q, want empty", body)
+	}
+	if n := len(store.sessions); n != 0 {
+		t.Errorf("%d sessions left after the sweep, want 0", n)
+	}
+
+	cookie = get("/login", nil).Result().Cookies()[0]
+	w := get("/logout", cookie)
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge != -1 {
+		t.Errorf("logout cookies = %v, want one removing the session", c)
+	}
+	if body := get("/me", cookie).Body.String(); body != "" {
+		t.Errorf("me after logout = %q, want empty", body)
+	}
+}
+
+func TestFlashAfterRedirect(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.Use(Sessions("sid", NewCookieStore([]byte("secret"))))
+	e.POST("/save", func(c *Context) {
+		c.AddFlash("saved")
+		c.Redirect("/")
+	})
+	e.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, c.Flashes())
+	})
+
+	w := performRequest(e, http.MethodPost, "/save", nil)
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("save: got %d", w.Code)
+	}
+	cookie := w.Result().Cookies()[0]
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if body := w.Body.String(); body != `["saved"]`+"\n" {
+		t.Errorf("first read = %q, want the flash", body)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(w.Result().Cookies()[0])
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if body := w.Body.String(); body != "null\n" {
+		t.Errorf("second read = %q, want no flash", body)
+	}
+}
+
+func TestCookieStoreTampered(t *testing.T) {
+	SetMode(TestMode)
+	store := NewCookieStore([]byte("secret"))
+	e := New()
+	e.Use(Sessions("session", store))
+	e.GET("/login", func(c *Context) {
+		c.Session().Set("user", "ann")
+		c.Session().Save()
+	})
+	e.GET("/me", func(c *Context) {
+		user, _ := c.Session().Get("user").(string)
+		c.String(http.StatusOK, user)
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookie := w.Result().Cookies()[0]
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 1
+	tampered := &http.Cookie{Name: cookie.Name, Value: base64.RawURLEncoding.EncodeToString(data)}
+
+	r := httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.AddCookie(tampered)
+	if _, err := store.Load(newContext(httptest.NewRecorder(), r, nil), "session"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Load = %v, want ErrInvalidSession", err)
+	}
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if w.Body.String() != "" {
+		t.Errorf("tampered session read as %q, want it reset", w.Body.String())
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	if w.Body.String() != "ann" {
+		t.Errorf("intact session read as %q", w.Body.String())
+	}
+}
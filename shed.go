@@ -0,0 +1,22 @@
+package gen
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LoadShedding rejects new requests with 503 and Retry-After while overloaded reports true,
+// like a signal of queue depth or CPU pressure, before they consume any resources.
+// Requests already past it run to completion.
+func LoadShedding(overloaded func() bool, retryAfter time.Duration) HandlerFunc {
+	seconds := strconv.Itoa(int(retryAfter / time.Second))
+	return func(c *Context) {
+		if !overloaded() {
+			return
+		}
+		c.SetHeader("Retry-After", seconds)
+		c.errorPage(http.StatusServiceUnavailable, "Service Unavailable")
+		c.Abort()
+	}
+}
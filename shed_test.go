@@ -0,0 +1,36 @@
+package gen
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadShedding(t *testing.T) {
+	SetMode(TestMode)
+	var overloaded atomic.Bool
+	var served int
+	e := New()
+	e.Use(LoadShedding(overloaded.Load, 5*time.Second))
+	e.GET("/", func(c *Context) {
+		served++
+		c.String(http.StatusOK, "ok")
+	})
+
+	if w := performRequest(e, http.MethodGet, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("healthy: got %d", w.Code)
+	}
+	overloaded.Store(true)
+	w := performRequest(e, http.MethodGet, "/", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("overloaded: got %d with Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	overloaded.Store(false)
+	if w := performRequest(e, http.MethodGet, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("recovered: got %d", w.Code)
+	}
+	if served != 2 {
+		t.Errorf("handler ran %d times, want 2", served)
+	}
+}
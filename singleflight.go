@@ -0,0 +1,46 @@
+package gen
+
+import (
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// SingleFlight coalesces concurrent GET requests with the same key, as given by keyFunc:
+// the rest of the chain runs once and its response is replayed to all the waiting requests,
+// so e.g. a cache miss doesn't stampede the database. Requests with an empty key run alone.
+//
+// The replayed response carries the headers of the request that ran, so for user-specific data
+// keyFunc must include the caller's identity, like a user ID, not just the path. A response
+// setting cookies is never shared: the waiting requests run the chain themselves instead.
+func SingleFlight(keyFunc func(c *Context) string) HandlerFunc {
+	var group singleflight.Group
+	return func(c *Context) {
+		if c.Method != http.MethodGet {
+			return
+		}
+		key := keyFunc(c)
+		if key == "" {
+			return
+		}
+		ran := false
+		v, _, _ := group.Do(key, func() (any, error) {
+			ran = true
+			w := &recordWriter{ResponseWriter: c.Writer}
+			c.Writer = w
+			defer func() { c.Writer = w.ResponseWriter }()
+			c.Next()
+			if len(w.Header().Values("Set-Cookie")) > 0 {
+				return nil, nil
+			}
+			return &CachedResponse{
+				Status: w.Status(),
+				Header: w.Header().Clone(),
+				Body:   w.body.Bytes(),
+			}, nil
+		})
+		if res, ok := v.(*CachedResponse); ok && !ran {
+			c.replay(res)
+		}
+	}
+}
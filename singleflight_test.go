@@ -0,0 +1,65 @@
+package gen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight(t *testing.T) {
+	SetMode(TestMode)
+	for _, setCookie := range []bool{false, true} {
+		var runs atomic.Int32
+		release := make(chan struct{})
+		e := New()
+		e.Use(SingleFlight(func(c *Context) string { return c.Path }))
+		e.GET("/report", func(c *Context) {
+			n := runs.Add(1)
+			if n == 1 {
+				<-release
+			}
+			if setCookie {
+				c.SetCookie("sid", c.Query("user"), 0, "/", "", false, true)
+			}
+			c.String(http.StatusOK, "report")
+		})
+
+		const clients = 4
+		recorders := make([]*httptest.ResponseRecorder, clients)
+		var wg sync.WaitGroup
+		for i := range recorders {
+			recorders[i] = httptest.NewRecorder()
+			wg.Add(1)
+			go func(w *httptest.ResponseRecorder, user string) {
+				defer wg.Done()
+				e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report?user="+user, nil))
+			}(recorders[i], string(rune('a'+i)))
+		}
+		time.Sleep(50 * time.Millisecond) // let the others wait on the first
+		close(release)
+		wg.Wait()
+
+		want := int32(1)
+		if setCookie {
+			want = clients
+		}
+		if n := runs.Load(); n != want {
+			t.Errorf("setCookie=%v: the handler ran %d times, want %d", setCookie, n, want)
+		}
+		seen := map[string]bool{}
+		for _, w := range recorders {
+			if w.Code != http.StatusOK || w.Body.String() != "report" {
+				t.Errorf("setCookie=%v: got %d %q", setCookie, w.Code, w.Body.String())
+			}
+			for _, c := range w.Result().Cookies() {
+				if seen[c.Value] {
+					t.Errorf("cookie %q sent to two clients", c.Value)
+				}
+				seen[c.Value] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flushInterval is how often the stream writers flush to the client at most
+const flushInterval = 100 * time.Millisecond
+
+var ErrAlreadyWritten = errors.New("gen: response already written")
+
+// JSONArrayWriter streams a JSON array item by item, see Context.JSONArrayStream
+type JSONArrayWriter struct {
+	w         ResponseWriter
+	n         int
+	err       error // sticky write error, e.g. the client went away
+	lastFlush time.Time
+}
+
+// JSONArrayStream starts a JSON array response for endpoints producing large arrays
+// incrementally. Close must be called to end the array, which is [] without items.
+func (c *Context) JSONArrayStream(code int) (*JSONArrayWriter, error) {
+	if c.Writer.Written() {
+		return nil, ErrAlreadyWritten
+	}
+	c.setContentType(MIMEJSON)
+	c.Status(code)
+	if _, err := c.Writer.Write([]byte{'['}); err != nil {
+		return nil, err
+	}
+	return &JSONArrayWriter{w: c.Writer, lastFlush: time.Now()}, nil
+}
+
+// Write appends item to the array. An item failing to encode is skipped, the array stays valid,
+// while a failure to write ends the stream and is returned by every later call.
+func (aw *JSONArrayWriter) Write(item any) error {
+	if aw.err != nil {
+		return aw.err
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if aw.n > 0 {
+		data = append([]byte{','}, data...)
+	}
+	if _, aw.err = aw.w.Write(data); aw.err != nil {
+		return aw.err
+	}
+	aw.n++
+	if time.Since(aw.lastFlush) >= flushInterval {
+		aw.w.Flush()
+		aw.lastFlush = time.Now()
+	}
+	return nil
+}
+
+// Close ends the array and flushes it
+func (aw *JSONArrayWriter) Close() error {
+	if aw.err != nil {
+		return aw.err
+	}
+	if _, aw.err = aw.w.Write([]byte{']'}); aw.err != nil {
+		return aw.err
+	}
+	aw.w.Flush()
+	aw.err = errors.New("gen: JSON array stream closed")
+	return nil
+}
+
+// Stream calls step until it returns false, flushing after each call, for responses written
+// incrementally. It stops early when a write fails, e.g. the client went away, later writes
+// within the step failing fast, or when the request's context is done, returning the error.
+func (c *Context) Stream(step func(w io.Writer) bool) error {
+	w := &streamWriter{w: c.Writer}
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return c.Request.Context().Err()
+		default:
+		}
+		more := step(w)
+		if w.err != nil {
+			c.writeError(w.err)
+			return w.err
+		}
+		c.Writer.Flush()
+		if !more {
+			return nil
+		}
+	}
+}
+
+// streamWriter keeps the first write error, returned by every write after it
+type streamWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *streamWriter) Write(data []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(data)
+	w.err = err
+	return n, err
+}
+
+// SSEvent is a server-sent event. Data is written as is if it's a string, as JSON otherwise.
+type SSEvent struct {
+	Event string
+	ID    string
+	Retry time.Duration // the reconnection delay asked of the client, if non-zero
+	Data  any
+}
+
+// SSEStream writes the events from the channel as server-sent events until it's closed or the
+// client goes away. A heartbeat comment is sent every heartbeat, unless zero, so proxies don't
+// drop connections idle between events. It returns false if the client went away.
+func (c *Context) SSEStream(events <-chan SSEvent, heartbeat time.Duration) bool {
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	done := c.Request.Context().Done()
+	for {
+		var frame []byte
+		select {
+		case <-done:
+			return false
+		case <-tick:
+			frame = []byte(":\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return true
+			}
+			var err error
+			if frame, err = event.encode(); err != nil {
+				c.Error(err)
+				continue
+			}
+		}
+		if _, err := c.Writer.Write(frame); err != nil {
+			c.writeError(err)
+			return false
+		}
+		c.Writer.Flush()
+	}
+}
+
+func (e SSEvent) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if e.Event != "" {
+		buf.WriteString("event: " + e.Event + "\n")
+	}
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	data, ok := e.Data.(string)
+	if !ok {
+		b, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package gen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJSONArrayStream(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/:n", func(c *Context) {
+		aw, err := c.JSONArrayStream(http.StatusOK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := strconv.Atoi(c.Param("n"))
+		for i := 0; i < n; i++ {
+			aw.Write(H{"i": i})
+		}
+		aw.Write(func() {}) // can't be encoded, skipped
+		if err := aw.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	w := performRequest(e, http.MethodGet, "/3", nil)
+	var items []map[string]int
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("%v in %q", err, w.Body.String())
+	}
+	if len(items) != 3 || items[2]["i"] != 2 || w.Header().Get("Content-Type") != MIMEJSON {
+		t.Errorf("got %q as %q", w.Body.String(), w.Header().Get("Content-Type"))
+	}
+	if w = performRequest(e, http.MethodGet, "/0", nil); w.Body.String() != "[]" {
+		t.Errorf("no items: got %q, want []", w.Body.String())
+	}
+}
+
+func TestSSEStream(t *testing.T) {
+	SetMode(TestMode)
+	events := make(chan SSEvent, 1)
+	events <- SSEvent{Event: "tick", ID: "1", Data: H{"n": 1}}
+	e := New()
+	finished := true
+	e.GET("/events", func(c *Context) {
+		finished = c.SSEStream(events, 10*time.Millisecond)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "event: tick\nid: 1\ndata: {\"n\":1}\n\n") {
+		t.Errorf("body = %q, want the event first", body)
+	}
+	if n := strings.Count(body, ":\n\n"); n < 2 {
+		t.Errorf("got %d heartbeats in %q", n, body)
+	}
+	if finished || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("SSEStream = %v, Content-Type %q", finished, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestStreamStopsOnWriteError(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var steps int
+	var streamErr error
+	e.GET("/", func(c *Context) {
+		streamErr = c.Stream(func(w io.Writer) bool {
+			steps++
+			if _, err := fmt.Fprintf(w, "tick %d\n", steps); err == nil {
+				t.Error("write to a failing writer succeeded")
+			}
+			fmt.Fprintln(w, "more")
+			return steps < 100
+		})
+	})
+
+	w := &failingWriter{httptest.NewRecorder(), syscall.ECONNRESET}
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if steps != 1 {
+		t.Errorf("step ran %d times after the write failed", steps)
+	}
+	if !errors.Is(streamErr, syscall.ECONNRESET) {
+		t.Errorf("Stream = %v, want ECONNRESET", streamErr)
+	}
+}
@@ -0,0 +1,174 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout runs the rest of the chain with a deadline on the request's context.Context,
+// answering 504 if it's not done in time. The response of the chain is buffered until it's done,
+// so it can't be streamed or hijacked. Late writes of the slow handlers are dropped,
+// and the values they set are not seen by the middlewares before Timeout.
+func Timeout(timeout time.Duration) HandlerFunc {
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		tw := newTimeoutWriter(c.Writer)
+		// the rest of the chain runs on its own context, so the index and keys aren't shared
+		// with a goroutine that may outlive this middleware
+		child := &Context{
+			Writer:   tw,
+			Request:  c.Request.WithContext(ctx),
+			Path:     c.Path,
+			Method:   c.Method,
+			Params:   c.Params,
+			route:    c.route,
+			handlers: c.handlers[c.index+1:],
+			index:    -1,
+			engine:   c.engine,
+		}
+		c.mu.RLock()
+		for k, v := range c.Keys {
+			child.Set(k, v)
+		}
+		c.mu.RUnlock()
+		c.Abort()
+
+		done := make(chan struct{})
+		panicChan := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			defer child.runDeferred()
+			child.Next()
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+			child.mu.RLock()
+			for k, v := range child.Keys {
+				c.Set(k, v)
+			}
+			child.mu.RUnlock()
+			c.Errors = append(c.Errors, child.Errors...)
+			c.writeError(tw.finish())
+		case <-ctx.Done():
+			tw.timeout()
+		}
+	}
+}
+
+// timeoutWriter buffers the response of the chain run by Timeout, like http.TimeoutHandler,
+// so the slow handlers never touch the real response, which is only written by Timeout:
+// the buffered one if the chain finished in time, a 504 otherwise
+type timeoutWriter struct {
+	w           ResponseWriter
+	h           http.Header
+	buf         bytes.Buffer
+	mu          sync.Mutex
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func newTimeoutWriter(w ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{w: w, h: w.Header().Clone(), status: http.StatusOK}
+}
+
+// finish copies the buffered response out once the chain is done
+func (w *timeoutWriter) finish() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dst := w.w.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range w.h {
+		dst[k] = v
+	}
+	if !w.wroteHeader && w.buf.Len() == 0 {
+		return nil
+	}
+	w.w.WriteHeader(w.status)
+	_, err := w.w.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	if !w.w.Written() {
+		w.w.WriteHeader(http.StatusGatewayTimeout)
+	}
+}
+
+// Header returns the buffered headers, only read by Timeout once the chain is done
+func (w *timeoutWriter) Header() http.Header {
+	return w.h
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		debugPrint("WriteHeader(%d) dropped after timeout\n", code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		debugPrint("Write of %d bytes dropped after timeout\n", len(data))
+		return 0, http.ErrHandlerTimeout
+	}
+	w.wroteHeader = true
+	return w.buf.Write(data)
+}
+
+// Flush does nothing, the response is sent as a whole once the chain is done
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, ErrHijackNotSupported
+}
+
+func (w *timeoutWriter) Push(string, *http.PushOptions) error {
+	return http.ErrNotSupported
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wroteHeader
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Len()
+}
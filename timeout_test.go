@@ -0,0 +1,42 @@
+package gen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	e.GET("/fast", Timeout(time.Second), func(c *Context) {
+		c.SetHeader("X-Fast", "1")
+		c.String(http.StatusCreated, "done")
+	})
+	done := make(chan struct{})
+	e.GET("/slow", Timeout(20*time.Millisecond), func(c *Context) {
+		defer close(done)
+		// keeps setting headers across the deadline, racing with the 504 unless buffered
+		for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+			c.SetHeader("X-Late", time.Now().String())
+		}
+		c.String(http.StatusOK, "late")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "done" || w.Header().Get("X-Fast") != "1" {
+		t.Errorf("fast: got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("slow: got %d, want 504", w.Code)
+	}
+	if h := w.Result().Header.Get("X-Late"); h != "" {
+		t.Errorf("slow: late header leaked: %q", h)
+	}
+	<-done
+}
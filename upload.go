@@ -0,0 +1,174 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const HeaderUploadOffset = "Upload-Offset"
+
+// ErrInvalidUploadID is returned by UploadStore implementations for IDs they can't store,
+// and answered with 400
+var ErrInvalidUploadID = errors.New("invalid upload ID")
+
+// UploadStore keeps the data of resumable uploads by upload ID
+type UploadStore interface {
+	// Offset returns the number of bytes received for id, 0 for a new upload
+	Offset(id string) (int64, error)
+	// Append writes data to the end of upload id, returning the number of bytes written
+	Append(id string, data io.Reader) (int64, error)
+}
+
+type ResumableUploadConfig struct {
+	Store UploadStore
+	// ID returns the upload ID of the request, the route param "id" by default
+	ID func(c *Context) string
+	// OnComplete is called once the last byte of the upload, as declared by the
+	// total of Content-Range, is stored. It may write the response, 201 by default.
+	OnComplete func(c *Context, id string, size int64)
+}
+
+// ResumableUpload stores uploads sent in chunks, each a PUT, POST or PATCH whose Content-Range
+// like "bytes 0-1048575/5242880" must start at the current offset, so an interrupted upload
+// can resume after asking for the offset with a HEAD. The offset is reported in the
+// Upload-Offset header and the JSON body; chunks starting elsewhere get a 409 with it.
+func ResumableUpload(config ResumableUploadConfig) HandlerFunc {
+	if config.ID == nil {
+		config.ID = func(c *Context) string { return c.Param("id") }
+	}
+	var locks keyedMutex
+	return func(c *Context) {
+		id := config.ID(c)
+		if id == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": "missing upload ID"})
+			return
+		}
+		unlock := locks.lock(id)
+		defer unlock()
+		offset, err := config.Store.Offset(id)
+		if errors.Is(err, ErrInvalidUploadID) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, H{"error": err.Error()})
+			return
+		}
+		c.SetHeader(HeaderUploadOffset, strconv.FormatInt(offset, 10))
+		if c.Method == http.MethodHead || c.Method == http.MethodGet {
+			c.AbortWithStatus(http.StatusOK)
+			return
+		}
+		start, end, total, err := parseContentRange(c.Request.Header.Get("Content-Range"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": err.Error()})
+			return
+		}
+		if start != offset {
+			c.AbortWithStatusJSON(http.StatusConflict, H{"error": "chunk doesn't start at the offset", "offset": offset})
+			return
+		}
+		size := end - start + 1
+		n, err := config.Store.Append(id, io.LimitReader(c.Request.Body, size))
+		offset += n
+		c.SetHeader(HeaderUploadOffset, strconv.FormatInt(offset, 10))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, H{"error": err.Error(), "offset": offset})
+			return
+		}
+		if n != size {
+			c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": "chunk shorter than its Content-Range", "offset": offset})
+			return
+		}
+		if total < 0 || offset < total {
+			c.JSON(http.StatusOK, H{"offset": offset})
+			return
+		}
+		if config.OnComplete != nil {
+			config.OnComplete(c, id, offset)
+		}
+		if !c.Writer.Written() {
+			c.JSON(http.StatusCreated, H{"offset": offset, "complete": true})
+		}
+	}
+}
+
+// parseContentRange parses "bytes start-end/total", total being -1 for "*"
+func parseContentRange(s string) (start, end, total int64, err error) {
+	errInvalid := fmt.Errorf("invalid Content-Range %q", s)
+	spec, ok := strings.CutPrefix(s, "bytes ")
+	if !ok {
+		return 0, 0, 0, errInvalid
+	}
+	rng, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, 0, errInvalid
+	}
+	first, last, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, errInvalid
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, 0, errInvalid
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+		return 0, 0, 0, errInvalid
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil || total <= end {
+			return 0, 0, 0, errInvalid
+		}
+	}
+	return start, end, total, nil
+}
+
+// FileUploadStore is an UploadStore keeping each upload in a file of Dir named by its ID
+type FileUploadStore struct {
+	Dir string
+}
+
+// Path returns the file of upload id, e.g. to move it once complete
+func (s FileUploadStore) Path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return "", ErrInvalidUploadID
+	}
+	return filepath.Join(s.Dir, id), nil
+}
+
+func (s FileUploadStore) Offset(id string) (int64, error) {
+	path, err := s.Path(id)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s FileUploadStore) Append(id string, data io.Reader) (int64, error) {
+	path, err := s.Path(id)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
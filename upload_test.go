@@ -0,0 +1,60 @@
+package gen
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResumableUpload(t *testing.T) {
+	SetMode(TestMode)
+	store := FileUploadStore{Dir: t.TempDir()}
+	var completed string
+	var completedSize int64
+	e := New()
+	upload := ResumableUpload(ResumableUploadConfig{
+		Store: store,
+		OnComplete: func(c *Context, id string, size int64) {
+			completed, completedSize = id, size
+		},
+	})
+	e.PUT("/uploads/:id", upload)
+	e.HEAD("/uploads/:id", upload)
+
+	data := "first chunk|second chunk|last"
+	chunks := []string{data[:12], data[12:25], data[25:]}
+	var offset int
+	for i, chunk := range chunks {
+		rng := fmt.Sprintf("bytes %d-%d/%d", offset, offset+len(chunk)-1, len(data))
+		w := performRequest(e, http.MethodPut, "/uploads/report.bin", strings.NewReader(chunk), "Content-Range", rng)
+		offset += len(chunk)
+		want := http.StatusOK
+		if i == len(chunks)-1 {
+			want = http.StatusCreated
+		}
+		if w.Code != want || w.Header().Get(HeaderUploadOffset) != fmt.Sprint(offset) {
+			t.Errorf("chunk %d: got %d with offset %q", i, w.Code, w.Header().Get(HeaderUploadOffset))
+		}
+		if i == 0 {
+			// an interrupted client asks for the offset, and a replayed chunk conflicts
+			w = performRequest(e, http.MethodHead, "/uploads/report.bin", nil)
+			if w.Header().Get(HeaderUploadOffset) != "12" {
+				t.Errorf("HEAD: offset %q", w.Header().Get(HeaderUploadOffset))
+			}
+			w = performRequest(e, http.MethodPut, "/uploads/report.bin", strings.NewReader(chunk), "Content-Range", rng)
+			if w.Code != http.StatusConflict {
+				t.Errorf("replayed chunk: got %d", w.Code)
+			}
+		}
+	}
+
+	if completed != "report.bin" || completedSize != int64(len(data)) {
+		t.Errorf("OnComplete(%q, %d)", completed, completedSize)
+	}
+	path, _ := store.Path("report.bin")
+	if got, err := os.ReadFile(path); err != nil || string(got) != data {
+		t.Errorf("assembled %q, %v", got, err)
+	}
+}
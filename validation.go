@@ -0,0 +1,159 @@
+package gen
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a field failing a rule of its `binding` tag
+type FieldError struct {
+	Field string `json:"field"` // path like "Address.City"
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+func (e FieldError) Error() string {
+	if e.Param != "" {
+		return fmt.Sprintf("field %s fails rule %s=%s", e.Field, e.Rule, e.Param)
+	}
+	return fmt.Sprintf("field %s fails rule %s", e.Field, e.Rule)
+}
+
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks the struct pointed to by obj against the rules of its `binding` tags,
+// nested structs included, returning ValidationErrors. The rules are:
+//
+//	required      not the zero value
+//	min=n, max=n  bounds of numbers, or of the length of strings, slices and maps
+//	len=n         exact length of strings, slices and maps
+//	oneof=a b c   one of the space-separated values
+//
+// The binding option trim is allowed among them. A malformed tag, like min=abc or an unknown
+// rule, is returned as an error other than ValidationErrors.
+func Validate(obj any) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	if err := validateStruct(v, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		path := prefix + sf.Name
+		field := v.Field(i)
+		for _, rule := range strings.Split(sf.Tag.Get("binding"), ",") {
+			name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			ok, err := checkRule(field, name, param)
+			if err != nil {
+				return fmt.Errorf("gen: binding tag of %s: %w", path, err)
+			}
+			if !ok {
+				*errs = append(*errs, FieldError{Field: path, Rule: name, Param: param})
+			}
+		}
+		for field.Kind() == reflect.Pointer && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Struct && field.Type().PkgPath() != "time" {
+			p := path + "."
+			if sf.Anonymous {
+				p = prefix
+			}
+			if err := validateStruct(field, p, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRule reports whether field passes rule, binding options like trim always passing.
+// The error is of an unknown rule or a malformed param, regardless of the value.
+func checkRule(field reflect.Value, rule, param string) (bool, error) {
+	switch rule {
+	case "", "trim":
+		return true, nil
+	case "required":
+		return !field.IsZero(), nil
+	case "min", "max", "len", "oneof":
+	default:
+		return false, fmt.Errorf("unknown rule %q", rule)
+	}
+	var n float64
+	if rule == "min" || rule == "max" || rule == "len" {
+		var err error
+		if n, err = strconv.ParseFloat(param, 64); err != nil {
+			return false, fmt.Errorf("invalid param of rule %s: %q", rule, param)
+		}
+	}
+	for field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return true, nil // only required applies to a missing value
+		}
+		field = field.Elem()
+	}
+	switch rule {
+	case "min", "max", "len":
+		var size float64
+		switch field.Kind() {
+		case reflect.String:
+			size = float64(len([]rune(field.String())))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			size = float64(field.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			size = float64(field.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			size = float64(field.Uint())
+		case reflect.Float32, reflect.Float64:
+			size = field.Float()
+		default:
+			return true, nil
+		}
+		switch rule {
+		case "min":
+			return size >= n, nil
+		case "max":
+			return size <= n, nil
+		}
+		return size == n, nil
+	case "oneof":
+		value := fmt.Sprint(field.Interface())
+		for _, option := range strings.Fields(param) {
+			if value == option {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return true, nil
+}
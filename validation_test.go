@@ -0,0 +1,110 @@
+package gen
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateMalformedTag(t *testing.T) {
+	var bad struct {
+		Name string `binding:"min=abc"`
+	}
+	err := Validate(&bad)
+	var verrs ValidationErrors
+	if err == nil || errors.As(err, &verrs) {
+		t.Fatalf("err = %v, want a tag error", err)
+	}
+	if want := `gen: binding tag of Name: invalid param of rule min: "abc"`; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+
+	var nilPtr struct {
+		Age *int `binding:"max=x"`
+	}
+	if err := Validate(&nilPtr); err == nil {
+		t.Error("a malformed tag of a nil field passed")
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	type user struct {
+		Name string `binding:"required,min=2"`
+		Role string `binding:"oneof=admin user"`
+	}
+	if err := Validate(&user{Name: "ann", Role: "admin"}); err != nil {
+		t.Errorf("valid user: %v", err)
+	}
+	err := Validate(&user{Name: "a", Role: "root"})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 2 || verrs[0].Rule != "min" || verrs[1].Rule != "oneof" {
+		t.Errorf("err = %v, want min and oneof failures", err)
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	var typo struct {
+		Name string `binding:"requred"`
+	}
+	err := Validate(&typo)
+	if want := `gen: binding tag of Name: unknown rule "requred"`; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	var trimmed struct {
+		Name string `binding:"required,trim"`
+	}
+	if err := Validate(&trimmed); err == nil {
+		t.Error("required passed on an empty field")
+	}
+}
+
+func TestBindAndValidate(t *testing.T) {
+	SetMode(TestMode)
+	type signup struct {
+		Email string `json:"email" binding:"required"`
+		Age   int    `json:"age" binding:"min=18"`
+	}
+	type broken struct {
+		Email string `json:"email" binding:"min=abc"`
+	}
+	e := New()
+	e.POST("/signup", func(c *Context) {
+		var s signup
+		if c.BindAndValidate(&s) == nil {
+			c.String(http.StatusOK, "%s", s.Email)
+		}
+	})
+	e.POST("/broken", func(c *Context) {
+		var b broken
+		c.BindAndValidate(&b)
+	})
+
+	w := performRequest(e, http.MethodPost, "/signup", strings.NewReader(`{"email":`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("malformed: got %d %q", w.Code, w.Body)
+	}
+	w = performRequest(e, http.MethodPost, "/signup", strings.NewReader(`{"age":16}`), "Content-Type", MIMEJSON)
+	want := `{"error":"validation failed","fields":[{"field":"Email","rule":"required"},{"field":"Age","rule":"min","param":"18"}]}` + "\n"
+	if w.Code != http.StatusUnprocessableEntity || w.Body.String() != want {
+		t.Errorf("invalid: got %d %q", w.Code, w.Body)
+	}
+	w = performRequest(e, http.MethodPost, "/signup", strings.NewReader(`{"email":"a@b.c","age":30}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusOK || w.Body.String() != "a@b.c" {
+		t.Errorf("valid: got %d %q", w.Code, w.Body)
+	}
+
+	w = performRequest(e, http.MethodPost, "/broken", strings.NewReader(`{"email":"a@b.c"}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("malformed tag: got %d %q", w.Code, w.Body)
+	}
+	var handled []*Error
+	e.ErrorHandler(func(c *Context, errs []*Error) {
+		handled = errs
+		c.String(http.StatusInternalServerError, "handled")
+	})
+	w = performRequest(e, http.MethodPost, "/broken", strings.NewReader(`{"email":"a@b.c"}`), "Content-Type", MIMEJSON)
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "handled" || len(handled) != 1 {
+		t.Errorf("malformed tag with an ErrorHandler: got %d %q, handled %v", w.Code, w.Body, handled)
+	}
+}
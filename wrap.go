@@ -0,0 +1,43 @@
+package gen
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WrapH adapts a net/http handler into gen's chain, writing through Context.Writer
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// WrapF adapts a net/http handler function into gen's chain, writing through Context.Writer
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapH(f)
+}
+
+// StripPrefix removes prefix from the request path for the handlers after it, like
+// http.StripPrefix, for fronting a wrapped mux that matches the paths without it:
+// e.Any("/api/v1/*path", StripPrefix("/api/v1"), WrapH(mux)). It runs once gen has matched
+// the route, so it doesn't change which gen route matches; register gen's own routes under
+// a group like Version instead. Requests lacking the prefix get a 404.
+func StripPrefix(prefix string) HandlerFunc {
+	return func(c *Context) {
+		p, ok := strings.CutPrefix(c.Request.URL.Path, prefix)
+		if !ok {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		rp, _ := strings.CutPrefix(c.Request.URL.RawPath, prefix)
+		if p == "" {
+			p = "/"
+		}
+		u := *c.Request.URL
+		u.Path, u.RawPath = p, rp
+		req := c.Request.Clone(c.Request.Context())
+		req.URL = &u
+		c.Request = req
+		c.Path = p
+	}
+}
@@ -0,0 +1,58 @@
+package gen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripPrefixFrontsMux(t *testing.T) {
+	SetMode(TestMode)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user " + r.PathValue("id") + " at " + r.URL.Path))
+	})
+	e := New()
+	e.Any("/api/v1/*path", StripPrefix("/api/v1"), WrapH(mux))
+	e.GET("/other/*path", StripPrefix("/api/v1"), WrapH(mux))
+
+	for _, tt := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/api/v1/users/7", http.StatusOK, "user 7 at /users/7"},
+		{"/api/v1/nope", http.StatusNotFound, "404 page not found\n"},
+		{"/other/users/7", http.StatusNotFound, ""},
+	} {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestWrapStatus(t *testing.T) {
+	SetMode(TestMode)
+	e := New()
+	var status, size int
+	e.Use(func(c *Context) {
+		c.Next()
+		status, size = c.Writer.Status(), c.Writer.Size()
+	})
+	e.GET("/h", WrapH(http.NotFoundHandler()))
+	e.GET("/f", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("made"))
+	}))
+
+	w := performRequest(e, http.MethodGet, "/h", nil)
+	if w.Code != http.StatusNotFound || status != http.StatusNotFound || size != len("404 page not found\n") {
+		t.Errorf("WrapH: got %d, tracked %d of %d bytes", w.Code, status, size)
+	}
+	w = performRequest(e, http.MethodGet, "/f", nil)
+	if w.Code != http.StatusCreated || status != http.StatusCreated || size != 4 {
+		t.Errorf("WrapF: got %d, tracked %d of %d bytes", w.Code, status, size)
+	}
+}